@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pm/internal/config"
+	"pm/internal/detector"
+	"pm/internal/executor"
+	"pm/internal/project"
+	"pm/internal/translator"
+	"pm/internal/ui"
+)
+
+// translateCommand translates args for the detected package manager, scoped
+// to the selected workspace packages when --filter was given
+func translateCommand(result *detector.Result, opts globalOptions, args []string) (*translator.Command, error) {
+	pm := result.PackageManager
+	tr := translator.New(pm, translatorOptions(result, opts)...)
+
+	translated, err := tr.Translate(pm, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.filters) == 0 {
+		return translated, nil
+	}
+
+	names, err := selectWorkspacePackages(result, opts.filters)
+	if err != nil {
+		return nil, err
+	}
+	return tr.ForWorkspaces(translated, result.RootDir, names)
+}
+
+// runTranslated runs a translated command, or prints it with --dry-run,
+// returning the exit status
+func runTranslated(pm detector.PackageManager, corepack string, opts globalOptions, cmd *translator.Command) int {
+	reportTranslateWarnings(cmd)
+	if opts.dryRun {
+		executor.DryRun(os.Stdout, pm, corepack, cmd)
+		return 0
+	}
+	return fail(executor.Execute(pm, corepack, cmd))
+}
+
+// translatorOptions describes the package manager and package that commands are translated for
+func translatorOptions(result *detector.Result, opts globalOptions) []translator.Option {
+	options := []translator.Option{
+		translator.WithVersion(packageManagerVersion(result)),
+		translator.WithStrict(opts.strict || result.Config.Strict),
+		translator.WithHooks(hookPolicy(opts, result.Config)),
+		translator.WithDirectScripts(directScripts(opts, result.Config)),
+	}
+
+	// Filtered commands run in other packages, whose scripts the package manager resolves
+	if result.PackageDir != "" && len(opts.filters) == 0 {
+		if pkg, err := project.ReadPackageJSON(filepath.Join(result.PackageDir, "package.json")); err == nil {
+			options = append(options, translator.WithManifest(pkg))
+		}
+		options = append(options,
+			translator.WithBinDirs(project.BinDirs(result.PackageDir, result.RootDir)),
+			translator.WithPackageDir(result.PackageDir),
+		)
+	}
+	return options
+}
+
+// hookPolicy picks what happens to pre and post scripts, from --hooks and
+// --no-hooks or else the config
+func hookPolicy(opts globalOptions, cfg *config.Config) translator.HookPolicy {
+	policy := cfg.Hooks
+	if opts.hooks != "" {
+		policy = opts.hooks
+	}
+
+	switch policy {
+	case config.HooksAlways:
+		return translator.HooksRun
+	case config.HooksNever:
+		return translator.HooksSkip
+	}
+	return translator.HooksDefault
+}
+
+// directScripts reports whether scripts run with the shell rather than
+// through the package manager, from --direct and --no-direct or else the config
+func directScripts(opts globalOptions, cfg *config.Config) bool {
+	if opts.scripts != "" {
+		return opts.scripts == config.ScriptsDirect
+	}
+	return cfg.Scripts == config.ScriptsDirect
+}
+
+// loadTranslations extends the translation tables with the "translations"
+// of the user and project config, warning about entries that do not apply
+func loadTranslations(cfg *config.Config) {
+	if len(cfg.Translations) == 0 {
+		return
+	}
+
+	for _, data := range cfg.Translations {
+		if err := translator.Extend(data); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring config: %v\n", err)
+		}
+	}
+	for _, problem := range translator.Current().Validate() {
+		fmt.Fprintf(os.Stderr, "Warning: translations: %s\n", problem)
+	}
+}
+
+// reportTranslateWarnings prints the flags that were dropped or passed through unchecked
+func reportTranslateWarnings(cmd *translator.Command) {
+	for _, warning := range cmd.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
+
+// reportTranslateError prints why a command could not be translated,
+// suggesting similar commands when it was not recognized
+func reportTranslateError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+
+	var unknown *translator.UnknownCommandError
+	if errors.As(err, &unknown) {
+		if suggestions := ui.Suggest(unknown.Name, unknown.Candidates, 3); len(suggestions) > 0 {
+			fmt.Fprintf(os.Stderr, "Did you mean: %s?\n", strings.Join(suggestions, ", "))
+		}
+	}
+}
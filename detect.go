@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pm/internal/config"
+	"pm/internal/detector"
+	"pm/internal/project"
+	"pm/internal/ui"
+)
+
+// resolvePackageManager detects the package manager, reports detection
+// warnings and offers to clean up stale lockfiles when configured to.
+// A --pm option skips detection entirely.
+func resolvePackageManager(opts globalOptions) (*detector.Result, error) {
+	if opts.packageManager != "" {
+		return detector.ResolveForced(opts.packageManager)
+	}
+
+	result, err := detector.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if result.IsFallback() && len(result.Installed()) > 1 && ui.IsInteractive() {
+		if err := choosePackageManager(result); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	stale := result.StaleLockfiles()
+	if len(stale) > 0 && ui.IsInteractive() && result.Config.LockfilePolicy == config.LockfilePrompt {
+		removeStaleLockfiles(stale)
+	}
+
+	return result, nil
+}
+
+// choosePackageManager asks which installed package manager to use when
+// nothing in the project decided it, and offers to pin the choice in package.json
+func choosePackageManager(result *detector.Result) error {
+	pm, err := ui.ShowPackageManagerPrompt(result.Installed(), result.RootDir)
+	if err != nil {
+		return err
+	}
+	result.PackageManager = pm
+
+	// Deno is not managed through the packageManager field
+	if result.RootDir == "" || pm == detector.Deno {
+		return nil
+	}
+	version, err := detector.InstalledVersion(pm, result.RootDir)
+	if err != nil {
+		return nil
+	}
+
+	field := pm.Binary() + "@" + version
+	packageJSONPath := filepath.Join(result.RootDir, "package.json")
+	ok, err := ui.Confirm(fmt.Sprintf("Save \"packageManager\": %q to %s?", field, packageJSONPath))
+	if err != nil || !ok {
+		return nil
+	}
+	if err := project.SetPackageManager(packageJSONPath, field); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot update %s: %v\n", packageJSONPath, err)
+	}
+	return nil
+}
+
+func removeStaleLockfiles(paths []string) {
+	for _, path := range paths {
+		ok, err := ui.Confirm(fmt.Sprintf("Delete stale lockfile %s?", filepath.Base(path)))
+		if err != nil || !ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot delete %s: %v\n", path, err)
+		}
+	}
+}
+
+// packageManagerVersion returns the version that will run translated commands:
+// the pinned version when there is one, since EnsureVersion makes it run,
+// and the installed version otherwise
+func packageManagerVersion(result *detector.Result) string {
+	if result.Version != "" {
+		version, _ := detector.SplitIntegrity(result.Version)
+		return version
+	}
+
+	version, err := detector.InstalledVersion(result.PackageManager, result.RootDir)
+	if err != nil {
+		return ""
+	}
+	return version
+}
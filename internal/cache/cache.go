@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Fingerprint records the state of the files an entry was derived from.
+// An entry is only valid while every file still has the recorded state.
+type Fingerprint map[string]string
+
+// Stat fingerprints the given paths by modification time and size
+func Stat(paths ...string) Fingerprint {
+	fingerprint := make(Fingerprint, len(paths))
+	for _, path := range paths {
+		fingerprint[path] = fileState(path)
+	}
+	return fingerprint
+}
+
+func fileState(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "missing"
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// Valid reports whether none of the fingerprinted files changed
+func (f Fingerprint) Valid() bool {
+	for path, state := range f {
+		if fileState(path) != state {
+			return false
+		}
+	}
+	return true
+}
+
+// entry is the file stored for one key. The key is kept to tell apart keys
+// whose hashes collide.
+type entry struct {
+	Key         string          `json:"key"`
+	Fingerprint Fingerprint     `json:"fingerprint"`
+	Value       json.RawMessage `json:"value"`
+}
+
+// maxAge is how long an entry that is not read stays in the cache
+const maxAge = 30 * 24 * time.Hour
+
+// maxEntries caps the entries of a bucket; the least recently used go first
+var maxEntries = 1000
+
+// Dir returns the pm cache directory. PM_CACHE_DIR overrides the default
+// location under the user cache directory; "off" disables caching.
+func Dir() string {
+	if dir := os.Getenv("PM_CACHE_DIR"); dir != "" {
+		if dir == "off" {
+			return ""
+		}
+		return dir
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pm")
+}
+
+// entryPath is the file holding key in bucket, or "" with caching disabled.
+// Every key has its own file, so storing one entry rewrites nothing else.
+func entryPath(bucket, key string) string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, bucket, hex.EncodeToString(sum[:16])+".json")
+}
+
+// Load decodes the entry stored under key in bucket into v. It returns false
+// when the entry is missing or any of its fingerprinted files changed.
+func Load(bucket, key string, v any) bool {
+	path := entryPath(bucket, key)
+	if path == "" {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var e entry
+	// A corrupt entry behaves like a missing one
+	if json.Unmarshal(data, &e) != nil || e.Key != key || !e.Fingerprint.Valid() {
+		return false
+	}
+	if json.Unmarshal(e.Value, v) != nil {
+		return false
+	}
+
+	// The modification time records the last use, for eviction
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return true
+}
+
+// Store saves v under key in bucket, fingerprinting the given paths. Entries
+// unused for maxAge, and the least recently used beyond maxEntries, are evicted.
+func Store(bucket, key string, paths []string, v any) error {
+	path := entryPath(bucket, key)
+	if path == "" {
+		return nil
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Key: key, Fingerprint: Stat(paths...), Value: value})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Write atomically so concurrent pm processes never see a partial file
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	evict(dir)
+	return nil
+}
+
+// evict removes the entries of a bucket directory unused for maxAge, and the
+// least recently used ones beyond maxEntries
+func evict(dir string) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type stored struct {
+		path    string
+		modTime time.Time
+	}
+	var entries []stored
+	cutoff := time.Now().Add(-maxAge)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if info.ModTime().Before(cutoff) {
+			_ = os.Remove(path)
+			continue
+		}
+		entries = append(entries, stored{path: path, modTime: info.ModTime()})
+	}
+
+	if len(entries) <= maxEntries {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.After(entries[j].modTime) })
+	for _, e := range entries[maxEntries:] {
+		_ = os.Remove(e.path)
+	}
+}
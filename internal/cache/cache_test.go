@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreAndLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CACHE_DIR", filepath.Join(tmpDir, "cache"))
+
+	watched := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(watched, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := Store("test", "key", []string{watched}, "value"); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	var got string
+	if !Load("test", "key", &got) || got != "value" {
+		t.Fatalf("Load() = %q, want value", got)
+	}
+
+	if Load("test", "other", &got) {
+		t.Error("Load() of a missing key returned true")
+	}
+}
+
+func TestLoadInvalidatedByChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CACHE_DIR", filepath.Join(tmpDir, "cache"))
+
+	watched := filepath.Join(tmpDir, "pnpm-lock.yaml")
+	missing := filepath.Join(tmpDir, "package-lock.json")
+	if err := os.WriteFile(watched, []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := Store("test", "key", []string{watched, missing}, 1); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	// Creating a previously missing file invalidates the entry
+	if err := os.WriteFile(missing, []byte(""), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	var got int
+	if Load("test", "key", &got) {
+		t.Error("Load() returned true after a fingerprinted file appeared")
+	}
+
+	if err := Store("test", "key", []string{watched}, 2); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(watched, later, later); err != nil {
+		t.Fatalf("Failed to touch file: %v", err)
+	}
+	if Load("test", "key", &got) {
+		t.Error("Load() returned true after a fingerprinted file changed")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	t.Setenv("PM_CACHE_DIR", "off")
+
+	if err := Store("test", "key", nil, 1); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	var got int
+	if Load("test", "key", &got) {
+		t.Error("Load() returned true with caching disabled")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CACHE_DIR", filepath.Join(tmpDir, "cache"))
+	defer func(saved int) { maxEntries = saved }(maxEntries)
+
+	age := func(key string, d time.Duration) {
+		then := time.Now().Add(-d)
+		if err := os.Chtimes(entryPath("test", key), then, then); err != nil {
+			t.Fatalf("Failed to age %s: %v", key, err)
+		}
+	}
+
+	for _, key := range []string{"stale", "old", "recent"} {
+		if err := Store("test", key, nil, key); err != nil {
+			t.Fatalf("Store(%s) error = %v", key, err)
+		}
+	}
+	age("stale", maxAge+time.Hour)
+	age("old", 2*time.Hour)
+	age("recent", time.Hour)
+
+	// Reading an entry counts as using it
+	var got string
+	if !Load("test", "old", &got) {
+		t.Fatal("Load(old) = false, want true")
+	}
+
+	maxEntries = 2
+	if err := Store("test", "new", nil, "new"); err != nil {
+		t.Fatalf("Store(new) error = %v", err)
+	}
+	for key, want := range map[string]bool{"stale": false, "recent": false, "old": true, "new": true} {
+		if _, err := os.Stat(entryPath("test", key)); (err == nil) != want {
+			t.Errorf("entry %s kept = %v, want %v", key, err == nil, want)
+		}
+	}
+}
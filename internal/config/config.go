@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFileName is the name of the per-project config file, read from the workspace root
+const ProjectFileName = ".pmrc.json"
+
+// Lockfile policies applied when more than one lockfile is found
+const (
+	LockfileWarn     = "warn"
+	LockfileError    = "error"
+	LockfilePriority = "priority"
+	LockfilePrompt   = "prompt"
+)
+
+// Corepack policies applied when the installed package manager does not match
+// the version pinned by the packageManager field
+const (
+	CorepackAuto   = "auto"
+	CorepackPrompt = "prompt"
+	CorepackError  = "error"
+	CorepackOff    = "off"
+)
+
+// Hook policies for the pre<script> and post<script> scripts around a script
+const (
+	HooksAuto   = "auto"
+	HooksAlways = "always"
+	HooksNever  = "never"
+)
+
+// Script runners for package.json scripts
+const (
+	ScriptsDirect         = "direct"
+	ScriptsPackageManager = "package-manager"
+)
+
+// Config holds user and project preferences for pm
+type Config struct {
+	// LockfilePolicy is one of "warn" (default), "error", "priority" or "prompt"
+	LockfilePolicy string `json:"lockfilePolicy"`
+	// LockfilePriority orders package managers when LockfilePolicy is "priority"
+	LockfilePriority []string `json:"lockfilePriority"`
+	// Corepack is one of "auto" (default), "prompt", "error" or "off"
+	Corepack string `json:"corepack"`
+	// DetectionOrder replaces the default order of detection strategies
+	DetectionOrder []string `json:"detectionOrder"`
+	// DisableDetection lists detection strategies to skip, e.g. "path"
+	DisableDetection []string `json:"disableDetection"`
+	// Strict fails commands with flags the package manager would drop or may not know
+	Strict bool `json:"strict"`
+	// Hooks is one of "auto" (default), "always" or "never"
+	Hooks string `json:"hooks"`
+	// Scripts is "package-manager" (default) to run scripts through the
+	// package manager, or "direct" to run them with the shell
+	Scripts string `json:"scripts"`
+	// Translations holds the "translations" of the user config followed by
+	// the project config, each extending pm's translation tables
+	Translations []json.RawMessage `json:"-"`
+}
+
+// Load reads the user config followed by the project config in rootDir.
+// Values from the project config override the user config.
+func Load(rootDir string) *Config {
+	cfg := &Config{}
+
+	if path := UserFilePath(); path != "" {
+		readFile(path, cfg)
+	}
+	if rootDir != "" {
+		readFile(filepath.Join(rootDir, ProjectFileName), cfg)
+	}
+
+	if cfg.LockfilePolicy == "" {
+		cfg.LockfilePolicy = LockfileWarn
+	}
+	if cfg.Corepack == "" {
+		cfg.Corepack = CorepackAuto
+	}
+	if cfg.Hooks == "" {
+		cfg.Hooks = HooksAuto
+	}
+	if cfg.Scripts == "" {
+		cfg.Scripts = ScriptsPackageManager
+	}
+
+	return cfg
+}
+
+// UserFilePath returns the location of the user config file.
+// PM_CONFIG overrides the default location under the user config directory.
+func UserFilePath() string {
+	if path := os.Getenv("PM_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pm", "config.json")
+}
+
+func readFile(path string, cfg *Config) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	// Ignore malformed files the same way a missing file is ignored
+	if json.Unmarshal(data, cfg) != nil {
+		return
+	}
+
+	// Translations extend each other rather than the project replacing the user's
+	var extensions struct {
+		Translations json.RawMessage `json:"translations"`
+	}
+	if json.Unmarshal(data, &extensions) == nil && len(extensions.Translations) > 0 {
+		cfg.Translations = append(cfg.Translations, extensions.Translations)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+
+	cfg := Load(tmpDir)
+	if cfg.LockfilePolicy != LockfileWarn {
+		t.Errorf("LockfilePolicy = %q, want %q", cfg.LockfilePolicy, LockfileWarn)
+	}
+	if cfg.Corepack != CorepackAuto {
+		t.Errorf("Corepack = %q, want %q", cfg.Corepack, CorepackAuto)
+	}
+	if cfg.Strict {
+		t.Error("Strict = true, want false by default")
+	}
+	if cfg.Hooks != HooksAuto {
+		t.Errorf("Hooks = %q, want %q", cfg.Hooks, HooksAuto)
+	}
+	if cfg.Scripts != ScriptsPackageManager {
+		t.Errorf("Scripts = %q, want %q", cfg.Scripts, ScriptsPackageManager)
+	}
+}
+
+func TestLoadProjectOverridesUser(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	userConfig := filepath.Join(tmpDir, "user.json")
+	if err := os.WriteFile(userConfig, []byte(`{"lockfilePolicy":"error","lockfilePriority":["yarn"],"strict":true}`), 0644); err != nil {
+		t.Fatalf("Failed to create user config: %v", err)
+	}
+	t.Setenv("PM_CONFIG", userConfig)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ProjectFileName), []byte(`{"lockfilePolicy":"priority"}`), 0644); err != nil {
+		t.Fatalf("Failed to create project config: %v", err)
+	}
+
+	cfg := Load(projectDir)
+	if cfg.LockfilePolicy != LockfilePriority {
+		t.Errorf("LockfilePolicy = %q, want %q", cfg.LockfilePolicy, LockfilePriority)
+	}
+	if len(cfg.LockfilePriority) != 1 || cfg.LockfilePriority[0] != "yarn" {
+		t.Errorf("LockfilePriority = %v, want [yarn] from the user config", cfg.LockfilePriority)
+	}
+	if !cfg.Strict {
+		t.Error("Strict = false, want true from the user config")
+	}
+}
+
+func TestLoadTranslationsFromBothConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	userConfig := filepath.Join(tmpDir, "user.json")
+	if err := os.WriteFile(userConfig, []byte(`{"translations":{"valueFlags":["user"]}}`), 0644); err != nil {
+		t.Fatalf("Failed to create user config: %v", err)
+	}
+	t.Setenv("PM_CONFIG", userConfig)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ProjectFileName), []byte(`{"translations":{"valueFlags":["project"]}}`), 0644); err != nil {
+		t.Fatalf("Failed to create project config: %v", err)
+	}
+
+	cfg := Load(projectDir)
+	got := []string{}
+	for _, data := range cfg.Translations {
+		got = append(got, string(data))
+	}
+	want := []string{`{"valueFlags":["user"]}`, `{"valueFlags":["project"]}`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Translations = %q, want %q", got, want)
+	}
+}
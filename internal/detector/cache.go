@@ -0,0 +1,74 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pm/internal/cache"
+	"pm/internal/config"
+)
+
+const detectionBucket = "detection"
+
+// environmentStrategies depend on the environment rather than on project files
+var environmentStrategies = []string{StrategyOverride, StrategyUserAgent, StrategyPath}
+
+// runCached runs the chain through the on-disk cache. Only decisions made from
+// project files are cached; entries are keyed by package and workspace root
+// and stay valid while none of the files detection reads change.
+func (d *detection) runCached(chain []Strategy) (bool, error) {
+	if d.location == nil || cache.Dir() == "" {
+		return d.run(chain)
+	}
+
+	key := strings.Join([]string{
+		d.location.PackageDir,
+		d.location.WorkspaceRoot,
+		config.UserFilePath(),
+		// Environment strategies that ran before the decision left evidence behind
+		os.Getenv(overrideEnv),
+		os.Getenv(userAgentEnv),
+	}, "@")
+
+	var cached Result
+	if cache.Load(detectionBucket, key, &cached) && cached.PackageManager != "" {
+		cached.Config = d.cfg
+		*d.result = cached
+		return true, nil
+	}
+
+	found, err := d.run(chain)
+	if err != nil || !found || contains(environmentStrategies, d.decidedBy) {
+		return found, err
+	}
+
+	_ = cache.Store(detectionBucket, key, projectInputs(d.location), d.result)
+	return true, nil
+}
+
+// projectInputs lists the paths the project strategies depend on, so that a
+// cached result is checked with few stats. Lockfiles, deno.json, yarn
+// indicators and install markers only matter by existence, which the
+// modification time of their directory covers; files that are read are listed.
+func projectInputs(location *Location) []string {
+	dirs := []string{location.WorkspaceRoot}
+	if location.IsWorkspacePackage() {
+		dirs = append(dirs, location.PackageDir)
+	}
+
+	var paths []string
+	if path := config.UserFilePath(); path != "" {
+		paths = append(paths, path)
+	}
+	paths = append(paths,
+		filepath.Join(location.WorkspaceRoot, config.ProjectFileName),
+		filepath.Join(location.WorkspaceRoot, ".yarn"),
+	)
+
+	for _, dir := range dirs {
+		paths = append(paths, dir, filepath.Join(dir, "package.json"), filepath.Join(dir, "node_modules"))
+	}
+
+	return paths
+}
@@ -6,66 +6,81 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
+
+	"pm/internal/config"
+	"pm/internal/semver"
 )
 
 const maxTraverseDepth = 20
 
+var denoConfigFiles = []string{"deno.json", "deno.jsonc"}
+
+// manifests mark the directory of a package
+var manifests = append([]string{"package.json"}, denoConfigFiles...)
+
+// stat is os.Stat; tests replace it to count the files detection looks at
+var stat = os.Stat
+
 func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
+	_, err := stat(filename)
 	return !os.IsNotExist(err)
 }
 
 type packageJSONConfig struct {
 	PackageManager string `json:"packageManager"`
+	DevEngines     struct {
+		PackageManager devEngineList `json:"packageManager"`
+	} `json:"devEngines"`
 }
 
-func detectPackageManager() (PackageManager, error) {
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("cannot get current directory: %v", err)
-	}
+// devEngineList accepts devEngines.packageManager as a single object or an array
+type devEngineList []DevEngine
 
-	rootDir, err := FindProjectRoot()
-	if err != nil {
-		return "", fmt.Errorf("no package manager detected")
+func (l *devEngineList) UnmarshalJSON(data []byte) error {
+	var single DevEngine
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = devEngineList{single}
+		return nil
 	}
 
-	packageJSONPath := filepath.Join(rootDir, "package.json")
-	packageManagerField := readPackageManagerField(packageJSONPath)
-
-	if fileExists(filepath.Join(rootDir, "package-lock.json")) {
-		return NPM, nil
-	} else if fileExists(filepath.Join(rootDir, "yarn.lock")) {
-		return detectYarnVariant(rootDir, packageManagerField), nil
-	} else if fileExists(filepath.Join(rootDir, "pnpm-lock.yaml")) {
-		return Pnpm, nil
-	} else if fileExists(filepath.Join(rootDir, "bun.lock")) || fileExists(filepath.Join(currentDir, "bun.lockb")) {
-		return Bun, nil
+	var list []DevEngine
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
 	}
+	*l = list
+	return nil
+}
 
-	if pm, ok := packageManagerFromField(packageManagerField); ok {
-		return pm, nil
+// devEngine returns the preferred devEngines.packageManager entry, if any
+func (c *packageJSONConfig) devEngine() *DevEngine {
+	for _, engine := range c.DevEngines.PackageManager {
+		if engine.Name != "" {
+			return &engine
+		}
 	}
+	return nil
+}
 
-	return "", fmt.Errorf("no package manager detected")
+func (c *packageJSONConfig) isEmpty() bool {
+	return c.PackageManager == "" && c.devEngine() == nil
 }
 
-func readPackageManagerField(packageJSONPath string) string {
+func readPackageManagerConfig(packageJSONPath string) *packageJSONConfig {
+	var packageJSON packageJSONConfig
+
 	file, err := os.Open(packageJSONPath)
 	if err != nil {
-		return ""
+		return &packageJSON
 	}
 	defer file.Close()
 
-	var packageJSON packageJSONConfig
 	decoder := json.NewDecoder(file)
 	if err := decoder.Decode(&packageJSON); err != nil {
-		return ""
+		return &packageJSONConfig{}
 	}
 
-	return packageJSON.PackageManager
+	return &packageJSON
 }
 
 func isCommandAvailable(name string) bool {
@@ -73,30 +88,95 @@ func isCommandAvailable(name string) bool {
 	return err == nil
 }
 
-func detectInstalledPackageManagers() (PackageManager, error) {
-	if isCommandAvailable("npm") {
-		return NPM, nil
+// Detect detects the package manager used in the current project
+func Detect() (PackageManager, error) {
+	result, err := Resolve()
+	if err != nil {
+		return "", err
 	}
-	if isCommandAvailable("yarn") {
-		return Yarn, nil
+	return result.PackageManager, nil
+}
+
+// Resolve detects the package manager used in the current project and records
+// the evidence that led to the decision. The result is returned even when
+// detection fails so callers can explain what was considered.
+func Resolve() (*Result, error) {
+	result := &Result{}
+	d := &detection{result: result}
+
+	rootDir := ""
+	if location, err := Locate(); err == nil {
+		d.location = location
+		rootDir = location.WorkspaceRoot
+		result.PackageDir = location.PackageDir
+		result.RootDir = location.WorkspaceRoot
+	} else {
+		result.reject(SourceProject, "package.json", "", "not found")
 	}
-	if isCommandAvailable("pnpm") {
-		return Pnpm, nil
+
+	d.cfg = config.Load(rootDir)
+	result.Config = d.cfg
+	chain, err := Chain(d.cfg)
+	if err != nil {
+		return result, err
+	}
+
+	found, err := d.runCached(chain)
+	if err != nil {
+		return result, err
 	}
-	if isCommandAvailable("bun") {
-		return Bun, nil
+	if found {
+		return result, nil
 	}
 
-	return "", fmt.Errorf("no package manager detected (supported: npm, yarn, pnpm, bun)")
+	return result, fmt.Errorf("no package manager detected (supported: npm, yarn, pnpm, bun, deno)")
 }
 
-// Detect detects the package manager used in the current project
-func Detect() (PackageManager, error) {
-	packageManager, err := detectPackageManager()
-	if err == nil {
-		return packageManager, nil
+func detectDenoConfig(result *Result, location *Location) bool {
+	dirs := []string{location.PackageDir}
+	if location.IsWorkspacePackage() {
+		dirs = append(dirs, location.WorkspaceRoot)
+	}
+
+	for _, dir := range dirs {
+		if path, ok := denoConfigPath(dir); ok {
+			result.accept(SourceDenoConfig, path, Deno)
+			return true
+		}
 	}
-	return detectInstalledPackageManagers()
+	result.reject(SourceDenoConfig, location.PackageDir, Deno, "no deno.json found")
+	return false
+}
+
+func lockfilePackageManager(lockfile string) PackageManager {
+	switch lockfile {
+	case "package-lock.json":
+		return NPM
+	case "yarn.lock":
+		return Yarn
+	case "pnpm-lock.yaml":
+		return Pnpm
+	case "deno.lock":
+		return Deno
+	default:
+		return Bun
+	}
+}
+
+func detectFromPath(result *Result) bool {
+	for _, pm := range []PackageManager{NPM, Yarn, Pnpm, Bun, Deno} {
+		if !isCommandAvailable(string(pm)) {
+			result.reject(SourcePath, string(pm), pm, "not installed")
+			continue
+		}
+		if result.PackageManager != "" {
+			result.reject(SourcePath, string(pm), pm, "lower priority than "+string(result.PackageManager))
+			continue
+		}
+		result.accept(SourcePath, string(pm), pm)
+	}
+
+	return result.PackageManager != ""
 }
 
 // FindPackageJSON traverses up the directory tree to find package.json
@@ -125,7 +205,7 @@ func FindPackageJSON() (string, error) {
 	return "", fmt.Errorf("package.json not found")
 }
 
-// FindProjectRoot finds the directory containing package.json by traversing up
+// FindProjectRoot finds the directory containing package.json (or deno.json) by traversing up
 func FindProjectRoot() (string, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -135,9 +215,10 @@ func FindProjectRoot() (string, error) {
 	depth := 0
 
 	for {
-		packageJSONPath := filepath.Join(currentDir, "package.json")
-		if fileExists(packageJSONPath) {
-			return currentDir, nil
+		for _, manifest := range manifests {
+			if fileExists(filepath.Join(currentDir, manifest)) {
+				return currentDir, nil
+			}
 		}
 
 		parentDir := filepath.Dir(currentDir)
@@ -151,30 +232,59 @@ func FindProjectRoot() (string, error) {
 	return "", fmt.Errorf("package.json not found")
 }
 
+// FindDenoConfig traverses up the directory tree to find deno.json or deno.jsonc
+func FindDenoConfig() (string, error) {
+	projectRoot, err := FindProjectRoot()
+	if err != nil {
+		return "", err
+	}
+
+	if path, ok := denoConfigPath(projectRoot); ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("deno.json not found")
+}
+
+func denoConfigPath(dir string) (string, bool) {
+	for _, name := range denoConfigFiles {
+		path := filepath.Join(dir, name)
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
 func dirExists(path string) bool {
-	info, err := os.Stat(path)
+	info, err := stat(path)
 	if err != nil {
 		return false
 	}
 	return info.IsDir()
 }
 
-func detectYarnVariant(rootDir, packageManagerField string) PackageManager {
+func detectYarnVariant(result *Result, rootDir, packageManagerField string) PackageManager {
 	if pm, ok := packageManagerFromField(packageManagerField); ok {
 		if pm == Yarn || pm == YarnBerry {
 			return pm
 		}
 	}
 
-	if fileExists(filepath.Join(rootDir, ".yarnrc.yml")) || fileExists(filepath.Join(rootDir, ".yarnrc.yaml")) {
-		return YarnBerry
-	}
-	if fileExists(filepath.Join(rootDir, ".pnp.cjs")) || fileExists(filepath.Join(rootDir, ".pnp.mjs")) {
-		return YarnBerry
+	indicators := []string{".yarnrc.yml", ".yarnrc.yaml", ".pnp.cjs", ".pnp.mjs"}
+	for _, indicator := range indicators {
+		path := filepath.Join(rootDir, indicator)
+		if fileExists(path) {
+			result.note(SourceYarnIndicator, path, YarnBerry)
+			return YarnBerry
+		}
 	}
-	if dirExists(filepath.Join(rootDir, ".yarn", "releases")) {
+	releasesDir := filepath.Join(rootDir, ".yarn", "releases")
+	if dirExists(releasesDir) {
+		result.note(SourceYarnIndicator, releasesDir, YarnBerry)
 		return YarnBerry
 	}
+
+	result.reject(SourceYarnIndicator, rootDir, YarnBerry, "no yarn berry files found")
 	return Yarn
 }
 
@@ -194,6 +304,8 @@ func packageManagerFromField(field string) (PackageManager, bool) {
 		return Pnpm, true
 	case "bun":
 		return Bun, true
+	case "deno":
+		return Deno, true
 	default:
 		return PackageManager(name), true
 	}
@@ -216,32 +328,9 @@ func parsePackageManagerParts(value string) (string, string) {
 }
 
 func isYarnBerryVersion(version string) bool {
-	major, ok := parseSemverMajor(version)
+	major, ok := semver.MinMajor(version)
 	if !ok {
 		return false
 	}
 	return major >= 2
 }
-
-func parseSemverMajor(version string) (int, bool) {
-	version = strings.TrimSpace(version)
-	if version == "" {
-		return 0, false
-	}
-
-	version = strings.TrimPrefix(version, "v")
-	end := 0
-	for end < len(version) && version[end] >= '0' && version[end] <= '9' {
-		end++
-	}
-	if end == 0 {
-		return 0, false
-	}
-
-	major, err := strconv.Atoi(version[:end])
-	if err != nil {
-		return 0, false
-	}
-
-	return major, true
-}
@@ -6,6 +6,12 @@ import (
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	// Keep tests away from the user's cache; cache tests opt back in
+	os.Setenv("PM_CACHE_DIR", "off")
+	os.Exit(m.Run())
+}
+
 func TestPackageManagerType(t *testing.T) {
 	tests := []struct {
 		pm   PackageManager
@@ -16,6 +22,7 @@ func TestPackageManagerType(t *testing.T) {
 		{YarnBerry, "yarn-berry"},
 		{Pnpm, "pnpm"},
 		{Bun, "bun"},
+		{Deno, "deno"},
 	}
 
 	for _, tt := range tests {
@@ -297,3 +304,819 @@ func TestDetectPackageManagerByPackageJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestLocateWorkspaceRoot(t *testing.T) {
+	tests := []struct {
+		name      string
+		rootFiles map[string]string
+		// ownRoot means the package is not a member of the workspace above it
+		ownRoot bool
+	}{
+		{
+			name: "pnpm-workspace.yaml",
+			rootFiles: map[string]string{
+				"package.json":        `{"name":"root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+			},
+		},
+		{
+			name: "workspaces field",
+			rootFiles: map[string]string{
+				"package.json": `{"name":"root","workspaces":["packages/*"]}`,
+			},
+		},
+		{
+			name: "workspaces globstar",
+			rootFiles: map[string]string{
+				"package.json": `{"name":"root","workspaces":{"packages":["packages/**"]}}`,
+			},
+		},
+		{
+			name: "deno workspace",
+			rootFiles: map[string]string{
+				"deno.json": `{"workspace":["./packages/foo"]}`,
+			},
+		},
+		{
+			name: "lockfile without workspaces",
+			rootFiles: map[string]string{
+				"package.json": `{"name":"root"}`,
+				"yarn.lock":    "",
+			},
+			ownRoot: true,
+		},
+		{
+			name: "workspaces of other packages",
+			rootFiles: map[string]string{
+				"package.json":        `{"name":"root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - apps/*\n",
+				"pnpm-lock.yaml":      "",
+			},
+			ownRoot: true,
+		},
+		{
+			name: "workspaces excluding the package",
+			rootFiles: map[string]string{
+				"package.json": `{"name":"root","workspaces":["packages/*","!packages/foo"]}`,
+			},
+			ownRoot: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for name, content := range tt.rootFiles {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			packageDir := filepath.Join(tmpDir, "packages", "foo")
+			nestedDir := filepath.Join(packageDir, "src")
+			if err := os.MkdirAll(nestedDir, 0755); err != nil {
+				t.Fatalf("Failed to create nested dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(packageDir, "package.json"), []byte(`{"name":"foo"}`), 0644); err != nil {
+				t.Fatalf("Failed to create package.json: %v", err)
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(nestedDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			location, err := Locate()
+			if err != nil {
+				t.Fatalf("Locate() error = %v", err)
+			}
+
+			gotPackageDir, _ := filepath.EvalSymlinks(location.PackageDir)
+			wantPackageDir, _ := filepath.EvalSymlinks(packageDir)
+			if gotPackageDir != wantPackageDir {
+				t.Errorf("PackageDir = %s, want %s", gotPackageDir, wantPackageDir)
+			}
+
+			gotRoot, _ := filepath.EvalSymlinks(location.WorkspaceRoot)
+			wantRoot, _ := filepath.EvalSymlinks(tmpDir)
+			if tt.ownRoot {
+				wantRoot = wantPackageDir
+			}
+			if gotRoot != wantRoot {
+				t.Errorf("WorkspaceRoot = %s, want %s", gotRoot, wantRoot)
+			}
+		})
+	}
+}
+
+func TestLocateWithoutWorkspace(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+		t.Fatalf("Failed to create package.json: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	location, err := Locate()
+	if err != nil {
+		t.Fatalf("Locate() error = %v", err)
+	}
+	if location.IsWorkspacePackage() {
+		t.Errorf("IsWorkspacePackage() = true, want false (root %s, package %s)", location.WorkspaceRoot, location.PackageDir)
+	}
+}
+
+func TestDetectFromWorkspacePackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"package.json":                 `{"name":"root","private":true}`,
+		"pnpm-workspace.yaml":          "packages:\n  - packages/*\n",
+		"pnpm-lock.yaml":               "",
+		"packages/foo/package.json":    `{"name":"foo"}`,
+		"packages/foo/src/placeholder": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(filepath.Join(tmpDir, "packages", "foo", "src")); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	pm, err := Detect()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pm != Pnpm {
+		t.Errorf("Detect() = %s, want %s", pm, Pnpm)
+	}
+}
+
+func TestResolveRecordsEvidence(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	packageJSON := `{"name":"test","packageManager":"npm@10.2.0"}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(packageJSON), 0644); err != nil {
+		t.Fatalf("Failed to create package.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "pnpm-lock.yaml"), []byte(``), 0644); err != nil {
+		t.Fatalf("Failed to create pnpm-lock.yaml: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	result, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if result.PackageManager != Pnpm {
+		t.Errorf("PackageManager = %s, want %s", result.PackageManager, Pnpm)
+	}
+	if result.Version != "" {
+		t.Errorf("Version = %q, want empty for a disagreeing packageManager field", result.Version)
+	}
+
+	var lockfileUsed, fieldRejected bool
+	for _, evidence := range result.Evidence {
+		if evidence.Source == SourceLockfile && evidence.Accepted && evidence.PackageManager == Pnpm {
+			lockfileUsed = true
+		}
+		if evidence.Source == SourcePackageManagerField && !evidence.Accepted && evidence.PackageManager == NPM {
+			fieldRejected = true
+		}
+	}
+	if !lockfileUsed {
+		t.Errorf("expected accepted pnpm lockfile evidence, got %+v", result.Evidence)
+	}
+	if !fieldRejected {
+		t.Errorf("expected rejected npm packageManager evidence, got %+v", result.Evidence)
+	}
+}
+
+func TestResolveConflictingLockfiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		packageJSON  string
+		config       string
+		wantPM       PackageManager
+		wantErr      bool
+		wantWarnings int
+	}{
+		{
+			name:         "warn uses the first lockfile",
+			packageJSON:  `{"name":"test"}`,
+			wantPM:       NPM,
+			wantWarnings: 1,
+		},
+		{
+			name:         "warn prefers the packageManager field",
+			packageJSON:  `{"name":"test","packageManager":"pnpm@9.1.0"}`,
+			wantPM:       Pnpm,
+			wantWarnings: 1,
+		},
+		{
+			name:        "error refuses to guess",
+			packageJSON: `{"name":"test"}`,
+			config:      `{"lockfilePolicy":"error"}`,
+			wantErr:     true,
+		},
+		{
+			name:         "priority follows the configured order",
+			packageJSON:  `{"name":"test"}`,
+			config:       `{"lockfilePolicy":"priority","lockfilePriority":["pnpm","npm"]}`,
+			wantPM:       Pnpm,
+			wantWarnings: 0,
+		},
+		{
+			name:         "priority without a match warns",
+			packageJSON:  `{"name":"test"}`,
+			config:       `{"lockfilePolicy":"priority","lockfilePriority":["bun"]}`,
+			wantPM:       NPM,
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+
+			files := map[string]string{
+				"package.json":      tt.packageJSON,
+				"package-lock.json": "",
+				"pnpm-lock.yaml":    "",
+			}
+			if tt.config != "" {
+				files[".pmrc.json"] = tt.config
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			result, err := Resolve()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Resolve() expected error, got %s", result.PackageManager)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if result.PackageManager != tt.wantPM {
+				t.Errorf("PackageManager = %s, want %s", result.PackageManager, tt.wantPM)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Warnings = %v, want %d warning(s)", result.Warnings, tt.wantWarnings)
+			}
+			if stale := result.StaleLockfiles(); len(stale) != 1 {
+				t.Errorf("StaleLockfiles() = %v, want exactly one", stale)
+			}
+		})
+	}
+}
+
+func TestResolveWarnsWhenFieldDisagreesWithLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name":"test","packageManager":"pnpm@9.1.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to create package.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "package-lock.json"), []byte(``), 0644); err != nil {
+		t.Fatalf("Failed to create package-lock.json: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	result, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if result.PackageManager != NPM {
+		t.Errorf("PackageManager = %s, want %s", result.PackageManager, NPM)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one warning", result.Warnings)
+	}
+}
+
+func TestDetectDeno(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "deno.json",
+			files: map[string]string{"deno.json": `{"tasks":{"dev":"deno run main.ts"}}`},
+		},
+		{
+			name:  "deno.jsonc",
+			files: map[string]string{"deno.jsonc": "{\n  // tasks\n  \"tasks\": {}\n}"},
+		},
+		{
+			name: "deno.lock next to package.json",
+			files: map[string]string{
+				"package.json": `{"name":"test"}`,
+				"deno.lock":    `{}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			pm, err := Detect()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if pm != Deno {
+				t.Errorf("Detect() = %s, want %s", pm, Deno)
+			}
+		})
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"9.1.0\n", "9.1.0"},
+		{"1.22.19", "1.22.19"},
+		{"4.1.0-rc.1\n", "4.1.0-rc.1"},
+		{"deno 2.0.0 (stable, release, x86_64-unknown-linux-gnu)\nv8 12.9.202.13-rusty\ntypescript 5.6.2\n", "2.0.0"},
+		{"command not found", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := parseVersionOutput(tt.output); got != tt.want {
+				t.Errorf("parseVersionOutput(%q) = %q, want %q", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitIntegrity(t *testing.T) {
+	version, integrity := SplitIntegrity("9.1.0+sha512.abcdef")
+	if version != "9.1.0" || integrity != "sha512.abcdef" {
+		t.Errorf("SplitIntegrity() = (%q, %q), want (9.1.0, sha512.abcdef)", version, integrity)
+	}
+
+	version, integrity = SplitIntegrity("4.1.0")
+	if version != "4.1.0" || integrity != "" {
+		t.Errorf("SplitIntegrity() = (%q, %q), want (4.1.0, \"\")", version, integrity)
+	}
+}
+
+func TestPackageManagerFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		wantPM    PackageManager
+		wantOK    bool
+	}{
+		{"pnpm/9.1.0 npm/? node/v20.11.0 linux x64", Pnpm, true},
+		{"yarn/1.22.19 npm/? node/v18.19.0 darwin arm64", Yarn, true},
+		{"yarn/4.1.0 npm/? node/v20.11.0 linux x64", YarnBerry, true},
+		{"npm/10.2.4 node/v20.11.0 linux x64 workspaces/false", NPM, true},
+		{"bun/1.1.8 npm/? node/v21.6.0 linux x64", Bun, true},
+		{"cnpm/9.0.0 node/v20.11.0", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			pm, ok := packageManagerFromUserAgent(tt.userAgent)
+			if ok != tt.wantOK || pm != tt.wantPM {
+				t.Errorf("packageManagerFromUserAgent(%q) = (%s, %v), want (%s, %v)", tt.userAgent, pm, ok, tt.wantPM, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		lockfile  string
+		override  string
+		userAgent string
+		wantPM    PackageManager
+	}{
+		{
+			name:      "user agent is used without project evidence",
+			userAgent: "pnpm/9.1.0 npm/? node/v20.11.0 linux x64",
+			wantPM:    Pnpm,
+		},
+		{
+			name:      "lockfile wins over user agent",
+			lockfile:  "yarn.lock",
+			userAgent: "pnpm/9.1.0 npm/? node/v20.11.0 linux x64",
+			wantPM:    Yarn,
+		},
+		{
+			name:     "override wins over lockfile",
+			lockfile: "yarn.lock",
+			override: "bun",
+			wantPM:   Bun,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("PM_PACKAGE_MANAGER", tt.override)
+			t.Setenv("npm_config_user_agent", tt.userAgent)
+
+			if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+				t.Fatalf("Failed to create package.json: %v", err)
+			}
+			if tt.lockfile != "" {
+				if err := os.WriteFile(filepath.Join(tmpDir, tt.lockfile), []byte(``), 0644); err != nil {
+					t.Fatalf("Failed to create lockfile: %v", err)
+				}
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			pm, err := Detect()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if pm != tt.wantPM {
+				t.Errorf("Detect() = %s, want %s", pm, tt.wantPM)
+			}
+		})
+	}
+}
+
+func TestResolveDevEngines(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		wantPM      PackageManager
+		wantRange   string
+		wantOnFail  string
+	}{
+		{
+			name:        "object form",
+			packageJSON: `{"devEngines":{"packageManager":{"name":"pnpm","version":"^9.0.0","onFail":"warn"}}}`,
+			wantPM:      Pnpm,
+			wantRange:   "^9.0.0",
+			wantOnFail:  "warn",
+		},
+		{
+			name:        "array form uses the first entry",
+			packageJSON: `{"devEngines":{"packageManager":[{"name":"bun","version":">=1.1"},{"name":"npm"}]}}`,
+			wantPM:      Bun,
+			wantRange:   ">=1.1",
+		},
+		{
+			name:        "yarn range selects berry",
+			packageJSON: `{"devEngines":{"packageManager":{"name":"yarn","version":"^4.1.0"}}}`,
+			wantPM:      YarnBerry,
+			wantRange:   "^4.1.0",
+		},
+		{
+			name:        "packageManager field takes precedence",
+			packageJSON: `{"packageManager":"npm@10.2.0","devEngines":{"packageManager":{"name":"npm","version":">=10"}}}`,
+			wantPM:      NPM,
+			wantRange:   ">=10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("PM_PACKAGE_MANAGER", "")
+
+			if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(tt.packageJSON), 0644); err != nil {
+				t.Fatalf("Failed to create package.json: %v", err)
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			result, err := Resolve()
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if result.PackageManager != tt.wantPM {
+				t.Errorf("PackageManager = %s, want %s", result.PackageManager, tt.wantPM)
+			}
+			if result.DevEngine == nil {
+				t.Fatalf("DevEngine = nil, want %s", tt.wantRange)
+			}
+			if result.DevEngine.Version != tt.wantRange {
+				t.Errorf("DevEngine.Version = %q, want %q", result.DevEngine.Version, tt.wantRange)
+			}
+			if result.DevEngine.OnFail != tt.wantOnFail {
+				t.Errorf("DevEngine.OnFail = %q, want %q", result.DevEngine.OnFail, tt.wantOnFail)
+			}
+		})
+	}
+}
+
+func TestDetectFromInstallMarkers(t *testing.T) {
+	tests := []struct {
+		marker string
+		wantPM PackageManager
+	}{
+		{".modules.yaml", Pnpm},
+		{".yarn-integrity", Yarn},
+		{".yarn-state.yml", YarnBerry},
+		{".package-lock.json", NPM},
+		{".bun-tag", Bun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.marker, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("PM_PACKAGE_MANAGER", "")
+			t.Setenv("npm_config_user_agent", "")
+
+			if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+				t.Fatalf("Failed to create package.json: %v", err)
+			}
+			nodeModules := filepath.Join(tmpDir, "node_modules")
+			if err := os.MkdirAll(nodeModules, 0755); err != nil {
+				t.Fatalf("Failed to create node_modules: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(nodeModules, tt.marker), []byte(``), 0644); err != nil {
+				t.Fatalf("Failed to create marker: %v", err)
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			pm, err := Detect()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if pm != tt.wantPM {
+				t.Errorf("Detect() = %s, want %s", pm, tt.wantPM)
+			}
+		})
+	}
+}
+
+func TestResolveCachesProjectDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	t.Setenv("PM_CACHE_DIR", cacheDir)
+	t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+	t.Setenv("PM_PACKAGE_MANAGER", "")
+
+	for name, content := range map[string]string{"package.json": `{"name":"test"}`, "yarn.lock": ""} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	first, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if entries, err := os.ReadDir(filepath.Join(cacheDir, detectionBucket)); err != nil || len(entries) == 0 {
+		t.Fatalf("detection was not cached: %v", err)
+	}
+
+	cached, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if cached.PackageManager != first.PackageManager || len(cached.Evidence) != len(first.Evidence) {
+		t.Errorf("cached Resolve() = %s with %d evidence, want %s with %d", cached.PackageManager, len(cached.Evidence), first.PackageManager, len(first.Evidence))
+	}
+
+	// Swapping the lockfile must invalidate the entry
+	if err := os.Remove(filepath.Join(tmpDir, "yarn.lock")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "pnpm-lock.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if result.PackageManager != Pnpm {
+		t.Errorf("PackageManager after lockfile change = %s, want pnpm", result.PackageManager)
+	}
+}
+
+func TestResolveCacheHitSkipsDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CACHE_DIR", filepath.Join(t.TempDir(), "cache"))
+	t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+	t.Setenv("PM_PACKAGE_MANAGER", "")
+	t.Setenv("npm_config_user_agent", "")
+
+	files := map[string]string{
+		"package.json":              `{"name":"root","workspaces":["packages/*"]}`,
+		"pnpm-lock.yaml":            "",
+		"packages/foo/package.json": `{"name":"foo"}`,
+		"packages/foo/src/index.js": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(filepath.Join(tmpDir, "packages", "foo", "src")); err != nil {
+		t.Fatalf("Failed to change dir: %v", err)
+	}
+
+	if _, err := Resolve(); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	var stats []string
+	defer func(original func(string) (os.FileInfo, error)) { stat = original }(stat)
+	stat = func(name string) (os.FileInfo, error) {
+		stats = append(stats, name)
+		return os.Stat(name)
+	}
+
+	result, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if result.PackageManager != Pnpm || result.Config == nil {
+		t.Errorf("cached Resolve() = %s with config %v, want pnpm with a config", result.PackageManager, result.Config)
+	}
+	if _, err := Locate(); err != nil {
+		t.Fatalf("Locate() error = %v", err)
+	}
+	if len(stats) > 0 {
+		t.Errorf("a cache hit looked at %d files, want none: %v", len(stats), stats)
+	}
+
+	location, _ := Locate()
+	if inputs := projectInputs(location); len(inputs) > 12 {
+		t.Errorf("projectInputs() = %d paths, want at most 12: %v", len(inputs), inputs)
+	}
+}
+
+func TestDetectionOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		config       string
+		wantPM       PackageManager
+		wantWarnings int
+		wantErr      bool
+	}{
+		{
+			name:         "lockfile wins by default",
+			files:        map[string]string{"package.json": `{"packageManager":"yarn@1.22.19"}`, "pnpm-lock.yaml": ""},
+			wantPM:       Pnpm,
+			wantWarnings: 1,
+		},
+		{
+			name:         "packageManager field can win over lockfiles",
+			files:        map[string]string{"package.json": `{"packageManager":"yarn@1.22.19"}`, "pnpm-lock.yaml": ""},
+			config:       `{"detectionOrder":["packageManager","lockfile"]}`,
+			wantPM:       Yarn,
+			wantWarnings: 1,
+		},
+		{
+			name:   "disabled strategies are skipped",
+			files:  map[string]string{"package.json": `{"packageManager":"yarn@1.22.19"}`, "pnpm-lock.yaml": ""},
+			config: `{"disableDetection":["lockfile"]}`,
+			wantPM: Yarn,
+		},
+		{
+			name:    "disabling the PATH fallback fails without project evidence",
+			files:   map[string]string{"package.json": `{"name":"test"}`},
+			config:  `{"disableDetection":["path"]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown strategies are rejected",
+			files:   map[string]string{"package.json": `{"name":"test"}`},
+			config:  `{"detectionOrder":["lockfile","registry"]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("PM_CONFIG", filepath.Join(tmpDir, "missing.json"))
+			t.Setenv("PM_PACKAGE_MANAGER", "")
+			t.Setenv("npm_config_user_agent", "")
+
+			files := tt.files
+			if tt.config != "" {
+				files[".pmrc.json"] = tt.config
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			result, err := Resolve()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Resolve() expected error, got %s", result.PackageManager)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if result.PackageManager != tt.wantPM {
+				t.Errorf("PackageManager = %s, want %s", result.PackageManager, tt.wantPM)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Warnings = %v, want %d warning(s)", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package detector
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"pm/internal/config"
+)
+
+const (
+	overrideEnv  = "PM_PACKAGE_MANAGER"
+	userAgentEnv = "npm_config_user_agent"
+)
+
+// detectFromOverride honors an explicit PM_PACKAGE_MANAGER such as "pnpm" or "yarn@4.1.0"
+func detectFromOverride(result *Result) bool {
+	value := strings.TrimSpace(os.Getenv(overrideEnv))
+	if value == "" {
+		result.reject(SourceOverride, overrideEnv, "", "not set")
+		return false
+	}
+
+	return applyOverride(result, overrideEnv+"="+value, value)
+}
+
+func applyOverride(result *Result, detail, value string) bool {
+	pm, ok := packageManagerFromField(value)
+	if !ok || !isSupported(pm) {
+		result.reject(SourceOverride, detail, pm, "unsupported package manager")
+		return false
+	}
+
+	result.accept(SourceOverride, detail, pm)
+	_, result.Version = parsePackageManagerParts(value)
+	return true
+}
+
+// ResolveForced returns a result for a package manager chosen explicitly,
+// e.g. with --pm, skipping every other detection stage
+func ResolveForced(value string) (*Result, error) {
+	result := &Result{}
+	if location, err := Locate(); err == nil {
+		result.PackageDir = location.PackageDir
+		result.RootDir = location.WorkspaceRoot
+	}
+	result.Config = config.Load(result.RootDir)
+
+	if !applyOverride(result, "--pm "+value, strings.TrimSpace(value)) {
+		return result, fmt.Errorf("unsupported package manager: %s (supported: npm, yarn, yarn-berry, pnpm, bun, deno)", value)
+	}
+	return result, nil
+}
+
+// detectFromUserAgent follows the package manager that invoked pm from a
+// package script, e.g. "pnpm/9.1.0 npm/? node/v20.11.0 linux x64"
+func detectFromUserAgent(result *Result) bool {
+	userAgent := strings.TrimSpace(os.Getenv(userAgentEnv))
+	if userAgent == "" {
+		result.reject(SourceUserAgent, userAgentEnv, "", "not set")
+		return false
+	}
+
+	pm, ok := packageManagerFromUserAgent(userAgent)
+	if !ok {
+		result.reject(SourceUserAgent, userAgent, "", "unrecognized user agent")
+		return false
+	}
+
+	result.accept(SourceUserAgent, userAgent, pm)
+	return true
+}
+
+func packageManagerFromUserAgent(userAgent string) (PackageManager, bool) {
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	name, version, _ := strings.Cut(product, "/")
+	if name == "" {
+		return "", false
+	}
+
+	pm, ok := packageManagerFromField(name + "@" + version)
+	if !ok || !isSupported(pm) {
+		return "", false
+	}
+	return pm, true
+}
+
+// ParsePackageManager parses a package manager name such as "pnpm",
+// "yarn-berry" or "yarn@4.1.0"
+func ParsePackageManager(value string) (PackageManager, bool) {
+	pm, ok := packageManagerFromField(value)
+	if !ok || !isSupported(pm) {
+		return "", false
+	}
+	return pm, true
+}
+
+func isSupported(pm PackageManager) bool {
+	return slices.Contains(Supported, pm)
+}
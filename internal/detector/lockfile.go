@@ -0,0 +1,82 @@
+package detector
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"pm/internal/config"
+)
+
+// resolveLockfileConflict picks the package manager among the lockfiles found
+// in the workspace root, applying the configured lockfile policy when more
+// than one package manager left a lockfile behind.
+func resolveLockfileConflict(result *Result, cfg *config.Config, candidates []PackageManager, fieldPM PackageManager) (PackageManager, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	names := make([]string, len(result.Lockfiles))
+	for i, path := range result.Lockfiles {
+		names[i] = filepath.Base(path)
+	}
+	found := strings.Join(names, ", ")
+
+	switch cfg.LockfilePolicy {
+	case config.LockfileError:
+		return "", fmt.Errorf("multiple lockfiles found (%s); remove the stale ones or configure lockfilePriority", found)
+	case config.LockfilePriority:
+		for _, name := range cfg.LockfilePriority {
+			pm := lockfileToolFromName(name)
+			if containsTool(candidates, pm) {
+				return pm, nil
+			}
+		}
+	}
+
+	chosen := candidates[0]
+	if fieldPM != "" && containsTool(candidates, fieldPM) {
+		chosen = lockfileToolFromName(string(fieldPM))
+	}
+
+	if cfg.LockfilePolicy == config.LockfilePriority {
+		result.warn(fmt.Sprintf("multiple lockfiles found (%s), none of them in lockfilePriority; using %s", found, chosen))
+	} else {
+		result.warn(fmt.Sprintf("multiple lockfiles found (%s); using %s", found, chosen))
+	}
+
+	return chosen, nil
+}
+
+// StaleLockfiles returns the lockfiles that do not belong to the detected package manager
+func (r *Result) StaleLockfiles() []string {
+	var stale []string
+	for _, path := range r.Lockfiles {
+		if !sameTool(lockfilePackageManager(filepath.Base(path)), r.PackageManager) {
+			stale = append(stale, path)
+		}
+	}
+	return stale
+}
+
+// lockfileToolFromName maps a package manager name to the tool owning its lockfile
+func lockfileToolFromName(name string) PackageManager {
+	if PackageManager(name) == YarnBerry {
+		return Yarn
+	}
+	return PackageManager(name)
+}
+
+func containsTool(pms []PackageManager, pm PackageManager) bool {
+	for _, candidate := range pms {
+		if sameTool(candidate, pm) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTool reports whether both package managers share a binary and lockfile
+func sameTool(a, b PackageManager) bool {
+	return lockfileToolFromName(string(a)) == lockfileToolFromName(string(b))
+}
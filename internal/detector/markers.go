@@ -0,0 +1,45 @@
+package detector
+
+import (
+	"path/filepath"
+)
+
+// installMarkers are files package managers leave in node_modules after an install
+var installMarkers = []struct {
+	file string
+	pm   PackageManager
+}{
+	{".modules.yaml", Pnpm},
+	{".yarn-state.yml", YarnBerry},
+	{".yarn-integrity", Yarn},
+	{".package-lock.json", NPM},
+	{".bun-tag", Bun},
+}
+
+// detectFromInstallMarkers infers the package manager from an existing
+// node_modules when the project has no lockfile or packageManager field
+func detectFromInstallMarkers(result *Result, location *Location) bool {
+	dirs := []string{location.WorkspaceRoot}
+	if location.IsWorkspacePackage() {
+		dirs = append(dirs, location.PackageDir)
+	}
+
+	for _, dir := range dirs {
+		nodeModules := filepath.Join(dir, "node_modules")
+		if !dirExists(nodeModules) {
+			result.reject(SourceInstallMarker, nodeModules, "", "not found")
+			continue
+		}
+
+		for _, marker := range installMarkers {
+			path := filepath.Join(nodeModules, marker.file)
+			if fileExists(path) {
+				result.accept(SourceInstallMarker, path, marker.pm)
+				return true
+			}
+		}
+		result.reject(SourceInstallMarker, nodeModules, "", "no install markers found")
+	}
+
+	return false
+}
@@ -0,0 +1,367 @@
+package detector
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"pm/internal/config"
+)
+
+// Strategy is one named step of the detection chain. Strategies run in the
+// configured order until one of them decides the package manager.
+type Strategy interface {
+	Name() string
+	// Detect records evidence on the result and reports whether it decided the package manager
+	Detect(d *detection) (bool, error)
+}
+
+// checker is implemented by strategies that cross-check a decision made by
+// an earlier strategy, e.g. to warn when the lockfile and packageManager disagree
+type checker interface {
+	Check(d *detection) error
+}
+
+// Strategy names accepted by the detectionOrder and disableDetection settings
+const (
+	StrategyOverride       = "override"
+	StrategyLockfile       = "lockfile"
+	StrategyPackageManager = "packageManager"
+	StrategyDenoConfig     = "deno"
+	StrategyInstallMarkers = "node_modules"
+	StrategyUserAgent      = "user-agent"
+	StrategyPath           = "path"
+)
+
+// DefaultOrder is the order strategies run in unless configured otherwise
+var DefaultOrder = []string{
+	StrategyOverride,
+	StrategyLockfile,
+	StrategyPackageManager,
+	StrategyDenoConfig,
+	StrategyInstallMarkers,
+	StrategyUserAgent,
+	StrategyPath,
+}
+
+var strategies = map[string]Strategy{
+	StrategyOverride:       strategyFunc{StrategyOverride, func(d *detection) (bool, error) { return detectFromOverride(d.result), nil }},
+	StrategyLockfile:       lockfileStrategy{},
+	StrategyPackageManager: packageManagerStrategy{},
+	StrategyDenoConfig:     projectStrategyFunc{StrategyDenoConfig, detectDenoConfig},
+	StrategyInstallMarkers: projectStrategyFunc{StrategyInstallMarkers, detectFromInstallMarkers},
+	StrategyUserAgent:      strategyFunc{StrategyUserAgent, func(d *detection) (bool, error) { return detectFromUserAgent(d.result), nil }},
+	StrategyPath:           strategyFunc{StrategyPath, func(d *detection) (bool, error) { return detectFromPath(d.result), nil }},
+}
+
+// Chain returns the strategies in the configured order. detectionOrder
+// replaces the default order and disableDetection removes strategies from it.
+func Chain(cfg *config.Config) ([]Strategy, error) {
+	order := DefaultOrder
+	if len(cfg.DetectionOrder) > 0 {
+		order = cfg.DetectionOrder
+	}
+
+	for _, name := range cfg.DisableDetection {
+		if _, ok := strategies[name]; !ok {
+			return nil, unknownStrategy(name)
+		}
+	}
+
+	var chain []Strategy
+	for _, name := range order {
+		strategy, ok := strategies[name]
+		if !ok {
+			return nil, unknownStrategy(name)
+		}
+		if contains(cfg.DisableDetection, name) {
+			continue
+		}
+		chain = append(chain, strategy)
+	}
+	return chain, nil
+}
+
+func unknownStrategy(name string) error {
+	return fmt.Errorf("unknown detection strategy %q (available: %s)", name, strings.Join(DefaultOrder, ", "))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// detection is the state shared by the strategies of a single detection run
+type detection struct {
+	result *Result
+	// location is nil outside of a project
+	location *Location
+	cfg      *config.Config
+	// decidedBy names the strategy that decided the package manager
+	decidedBy string
+	field     *manifestField
+}
+
+// run walks the chain until a strategy decides, then lets the remaining
+// strategies cross-check the decision
+func (d *detection) run(chain []Strategy) (bool, error) {
+	for i, strategy := range chain {
+		decided, err := strategy.Detect(d)
+		if err != nil {
+			return false, err
+		}
+		if !decided {
+			continue
+		}
+
+		d.decidedBy = strategy.Name()
+		for _, later := range chain[i+1:] {
+			if c, ok := later.(checker); ok {
+				if err := c.Check(d); err != nil {
+					return false, err
+				}
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// manifestField is the package manager requested by package.json, either
+// through the packageManager field or devEngines.packageManager
+type manifestField struct {
+	path      string
+	value     string
+	source    string
+	devEngine *DevEngine
+	pm        PackageManager
+	ok        bool
+}
+
+// packageManagerField reads the requested package manager once per run. The
+// workspace root wins; a workspace package is only consulted when the root
+// requests nothing.
+func (d *detection) packageManagerField() *manifestField {
+	if d.field != nil {
+		return d.field
+	}
+
+	path := filepath.Join(d.location.WorkspaceRoot, "package.json")
+	pkgConfig := readPackageManagerConfig(path)
+	if pkgConfig.isEmpty() && d.location.IsWorkspacePackage() {
+		path = filepath.Join(d.location.PackageDir, "package.json")
+		pkgConfig = readPackageManagerConfig(path)
+	}
+
+	field := &manifestField{
+		path:      path,
+		value:     pkgConfig.PackageManager,
+		source:    SourcePackageManagerField,
+		devEngine: pkgConfig.devEngine(),
+	}
+	// The packageManager field wins; devEngines.packageManager fills in when it is absent
+	if field.value == "" && field.devEngine != nil {
+		field.value = field.devEngine.field()
+		field.source = SourceDevEngines
+	}
+	field.pm, field.ok = packageManagerFromField(field.value)
+
+	d.field = field
+	return field
+}
+
+type strategyFunc struct {
+	name   string
+	detect func(d *detection) (bool, error)
+}
+
+func (s strategyFunc) Name() string { return s.name }
+
+func (s strategyFunc) Detect(d *detection) (bool, error) { return s.detect(d) }
+
+// projectStrategyFunc adapts a strategy that only applies inside a project
+type projectStrategyFunc struct {
+	name   string
+	detect func(result *Result, location *Location) bool
+}
+
+func (s projectStrategyFunc) Name() string { return s.name }
+
+func (s projectStrategyFunc) Detect(d *detection) (bool, error) {
+	if d.location == nil {
+		return false, nil
+	}
+	return s.detect(d.result, d.location), nil
+}
+
+// lockfileStrategy follows the lockfiles in the workspace root
+type lockfileStrategy struct{}
+
+func (lockfileStrategy) Name() string { return StrategyLockfile }
+
+func (lockfileStrategy) Detect(d *detection) (bool, error) {
+	if d.location == nil {
+		return false, nil
+	}
+
+	result := d.result
+	rootDir := d.location.WorkspaceRoot
+	field := d.packageManagerField()
+
+	candidates := findLockfiles(result, rootDir)
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	chosen, err := resolveLockfileConflict(result, d.cfg, candidates, field.pm)
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range result.Lockfiles {
+		pm := lockfilePackageManager(filepath.Base(path))
+		if !sameTool(pm, chosen) {
+			result.reject(SourceLockfile, path, pm, "conflicts with "+string(chosen)+" lockfile")
+			continue
+		}
+		if pm == Yarn {
+			pm = detectYarnVariant(result, rootDir, field.value)
+		}
+		result.accept(SourceLockfile, path, pm)
+	}
+
+	return result.PackageManager != "", nil
+}
+
+// Check records the lockfiles when package.json decided, warning about those
+// that belong to another package manager
+func (lockfileStrategy) Check(d *detection) error {
+	if d.location == nil || d.decidedBy != StrategyPackageManager {
+		return nil
+	}
+
+	result := d.result
+	if len(findLockfiles(result, d.location.WorkspaceRoot)) == 0 {
+		return nil
+	}
+
+	field := d.packageManagerField()
+	for _, path := range result.Lockfiles {
+		pm := lockfilePackageManager(filepath.Base(path))
+		if sameTool(pm, result.PackageManager) {
+			result.note(SourceLockfile, path, result.PackageManager)
+			continue
+		}
+
+		result.reject(SourceLockfile, path, pm, field.source+" field takes precedence")
+		message := fmt.Sprintf("%s field requests %s but %s belongs to %s", field.source, field.value, filepath.Base(path), pm)
+		if d.cfg.LockfilePolicy == config.LockfileError {
+			return fmt.Errorf("%s", message)
+		}
+		result.warn(message)
+	}
+	return nil
+}
+
+// findLockfiles records the lockfiles in rootDir and returns their package managers
+func findLockfiles(result *Result, rootDir string) []PackageManager {
+	var candidates []PackageManager
+	for _, lockfile := range lockfiles {
+		path := filepath.Join(rootDir, lockfile)
+		if !fileExists(path) {
+			result.reject(SourceLockfile, path, "", "not found")
+			continue
+		}
+
+		result.Lockfiles = append(result.Lockfiles, path)
+		pm := lockfilePackageManager(lockfile)
+		if !containsTool(candidates, pm) {
+			candidates = append(candidates, pm)
+		}
+	}
+	return candidates
+}
+
+// packageManagerStrategy follows the packageManager field, or
+// devEngines.packageManager when the field is absent
+type packageManagerStrategy struct{}
+
+func (packageManagerStrategy) Name() string { return StrategyPackageManager }
+
+func (packageManagerStrategy) Detect(d *detection) (bool, error) {
+	if d.location == nil {
+		return false, nil
+	}
+
+	field := d.packageManagerField()
+	if !field.ok {
+		d.result.reject(SourcePackageManagerField, field.path, "", "not set")
+		return false, nil
+	}
+
+	d.result.accept(field.source, field.path+": "+field.value, field.pm)
+	if field.source == SourcePackageManagerField {
+		_, d.result.Version = parsePackageManagerParts(field.value)
+	}
+	d.checkDevEngine()
+	return true, nil
+}
+
+// Check compares the field with the package manager the lockfile decided on
+func (packageManagerStrategy) Check(d *detection) error {
+	if d.location == nil || d.decidedBy != StrategyLockfile {
+		return nil
+	}
+
+	result := d.result
+	field := d.packageManagerField()
+	if !field.ok {
+		result.reject(SourcePackageManagerField, field.path, "", "not set")
+		d.checkDevEngine()
+		return nil
+	}
+
+	detail := field.path + ": " + field.value
+	if field.pm == result.PackageManager {
+		result.note(field.source, detail, field.pm)
+		if field.source == SourcePackageManagerField {
+			_, result.Version = parsePackageManagerParts(field.value)
+		}
+	} else {
+		result.reject(field.source, detail, field.pm, "lockfile takes precedence")
+		message := fmt.Sprintf("%s field requests %s but the lockfile belongs to %s", field.source, field.value, result.PackageManager)
+		if d.cfg.LockfilePolicy == config.LockfileError {
+			return fmt.Errorf("%s", message)
+		}
+		result.warn(message)
+	}
+
+	d.checkDevEngine()
+	return nil
+}
+
+// checkDevEngine attaches the devEngines.packageManager requirement when it
+// names the detected package manager and warns when it names another one
+func (d *detection) checkDevEngine() {
+	field := d.packageManagerField()
+	devEngine := field.devEngine
+	result := d.result
+	if devEngine == nil || result.PackageManager == "" {
+		return
+	}
+
+	detail := field.path + ": " + devEngine.field()
+	if sameTool(PackageManager(devEngine.Name), result.PackageManager) {
+		result.DevEngine = devEngine
+		if field.source != SourceDevEngines {
+			result.note(SourceDevEngines, detail, result.PackageManager)
+		}
+	} else {
+		result.reject(SourceDevEngines, detail, PackageManager(devEngine.Name), "does not match "+string(result.PackageManager))
+		result.warn(fmt.Sprintf("devEngines.packageManager requests %s but %s was detected", devEngine.Name, result.PackageManager))
+	}
+}
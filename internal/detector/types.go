@@ -1,5 +1,7 @@
 package detector
 
+import "pm/internal/config"
+
 type PackageManager string
 
 const (
@@ -8,4 +10,127 @@ const (
 	YarnBerry PackageManager = "yarn-berry"
 	Pnpm      PackageManager = "pnpm"
 	Bun       PackageManager = "bun"
+	Deno      PackageManager = "deno"
+)
+
+// Supported lists every package manager pm can drive
+var Supported = []PackageManager{NPM, Yarn, YarnBerry, Pnpm, Bun, Deno}
+
+// Evidence sources considered during detection
+const (
+	SourceOverride            = "override"
+	SourceProject             = "project"
+	SourceLockfile            = "lockfile"
+	SourcePackageManagerField = "packageManager"
+	SourceDevEngines          = "devEngines"
+	SourceYarnIndicator       = "yarn-berry"
+	SourceDenoConfig          = "deno.json"
+	SourceInstallMarker       = "node_modules"
+	SourceUserAgent           = "user-agent"
+	SourcePath                = "PATH"
 )
+
+// Result describes the detected package manager and how it was chosen
+type Result struct {
+	PackageManager PackageManager `json:"packageManager"`
+	// Version is the version constraint pinned by the packageManager field, if any
+	Version string `json:"version,omitempty"`
+	// DevEngine is the devEngines.packageManager requirement matching the detected package manager
+	DevEngine  *DevEngine `json:"devEngine,omitempty"`
+	PackageDir string     `json:"packageDir,omitempty"`
+	RootDir    string     `json:"rootDir,omitempty"`
+	// Lockfiles lists every lockfile found in the workspace root
+	Lockfiles []string   `json:"lockfiles,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+	Evidence  []Evidence `json:"evidence"`
+	// Config is the user and project config of the workspace, loaded once per run
+	Config *config.Config `json:"-"`
+}
+
+// DevEngine is a devEngines.packageManager entry from package.json
+type DevEngine struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// OnFail is one of "ignore", "warn" or "error" (the default)
+	OnFail string `json:"onFail,omitempty"`
+}
+
+// field renders the entry in packageManager field form, e.g. "pnpm@^9.0.0"
+func (e *DevEngine) field() string {
+	if e.Version == "" {
+		return e.Name
+	}
+	return e.Name + "@" + e.Version
+}
+
+// Evidence is a single signal that was considered during detection
+type Evidence struct {
+	Source         string         `json:"source"`
+	Detail         string         `json:"detail"`
+	PackageManager PackageManager `json:"packageManager,omitempty"`
+	Accepted       bool           `json:"accepted"`
+	Reason         string         `json:"reason,omitempty"`
+}
+
+// IsFallback reports whether the package manager was only chosen because it
+// is installed, without any evidence from the project or the environment
+func (r *Result) IsFallback() bool {
+	for _, evidence := range r.Evidence {
+		if evidence.Accepted && evidence.Source != SourcePath {
+			return false
+		}
+	}
+	return r.PackageManager != ""
+}
+
+// Installed returns the package managers found on PATH during detection
+func (r *Result) Installed() []PackageManager {
+	var installed []PackageManager
+	for _, evidence := range r.Evidence {
+		if evidence.Source == SourcePath && (evidence.Accepted || evidence.Reason != "not installed") {
+			installed = append(installed, evidence.PackageManager)
+		}
+	}
+	return installed
+}
+
+// accept records evidence that decided the package manager
+func (r *Result) accept(source, detail string, pm PackageManager) {
+	r.PackageManager = pm
+	r.note(source, detail, pm)
+}
+
+// note records evidence that contributed to the decision without making it
+func (r *Result) note(source, detail string, pm PackageManager) {
+	r.Evidence = append(r.Evidence, Evidence{
+		Source:         source,
+		Detail:         detail,
+		PackageManager: pm,
+		Accepted:       true,
+	})
+}
+
+// reject records evidence that was considered but not used
+func (r *Result) reject(source, detail string, pm PackageManager, reason string) {
+	r.Evidence = append(r.Evidence, Evidence{
+		Source:         source,
+		Detail:         detail,
+		PackageManager: pm,
+		Reason:         reason,
+	})
+}
+
+// warn records a problem that did not prevent detection
+func (r *Result) warn(message string) {
+	r.Warnings = append(r.Warnings, message)
+}
+
+// Binary returns the executable name of the package manager
+func (pm PackageManager) Binary() string {
+	switch pm {
+	case YarnBerry:
+		return "yarn"
+	default:
+		return string(pm)
+	}
+}
@@ -0,0 +1,88 @@
+package detector
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"pm/internal/cache"
+)
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+\.\d+[0-9A-Za-z.+-]*`)
+
+const versionBucket = "versions"
+
+type probedVersion struct {
+	fingerprint cache.Fingerprint
+	version     string
+}
+
+// probedVersions memoizes probes within a process, keyed like the disk cache
+var probedVersions sync.Map
+
+// InstalledVersion returns the version reported by `<pm> --version` in the
+// workspace at rootDir, which is empty outside of a project. The probe runs
+// once per process and is cached on disk until the binary, package.json or
+// .yarnrc.yml of the workspace changes, since corepack shims and yarnPath
+// make the answer depend on the project.
+func InstalledVersion(pm PackageManager, rootDir string) (string, error) {
+	binary, err := exec.LookPath(pm.Binary())
+	if err != nil {
+		return "", fmt.Errorf("cannot get %s version: %v", pm.Binary(), err)
+	}
+
+	paths := []string{binary}
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil && resolved != binary {
+		paths = append(paths, resolved)
+	}
+	if rootDir != "" {
+		paths = append(paths, filepath.Join(rootDir, "package.json"), filepath.Join(rootDir, ".yarnrc.yml"))
+	}
+	key := binary + "@" + rootDir
+
+	if probed, ok := probedVersions.Load(key); ok && probed.(probedVersion).fingerprint.Valid() {
+		return probed.(probedVersion).version, nil
+	}
+
+	var version string
+	if !cache.Load(versionBucket, key, &version) {
+		version, err = probeVersion(pm)
+		if err != nil {
+			return "", err
+		}
+		_ = cache.Store(versionBucket, key, paths, version)
+	}
+
+	probedVersions.Store(key, probedVersion{fingerprint: cache.Stat(paths...), version: version})
+	return version, nil
+}
+
+func probeVersion(pm PackageManager) (string, error) {
+	output, err := exec.Command(pm.Binary(), "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot get %s version: %v", pm.Binary(), err)
+	}
+
+	version := parseVersionOutput(string(output))
+	if version == "" {
+		return "", fmt.Errorf("cannot parse %s version from %q", pm.Binary(), strings.TrimSpace(string(output)))
+	}
+	return version, nil
+}
+
+// parseVersionOutput extracts the version from `--version` output such as
+// "9.1.0" or "deno 2.0.0 (stable, release, x86_64-unknown-linux-gnu)"
+func parseVersionOutput(output string) string {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	return versionPattern.FindString(firstLine)
+}
+
+// SplitIntegrity separates a pinned version like "9.1.0+sha512.abc" into the
+// version and its corepack integrity hash
+func SplitIntegrity(version string) (string, string) {
+	version, integrity, _ := strings.Cut(strings.TrimSpace(version), "+")
+	return version, integrity
+}
@@ -0,0 +1,142 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"pm/internal/jsonc"
+	"pm/internal/project"
+)
+
+// Location describes where the current package and its workspace root live
+type Location struct {
+	// PackageDir is the nearest directory containing package.json
+	PackageDir string
+	// WorkspaceRoot is the monorepo root; it equals PackageDir outside of workspaces
+	WorkspaceRoot string
+}
+
+// IsWorkspacePackage reports whether the package lives below a separate workspace root
+func (l *Location) IsWorkspacePackage() bool {
+	return l.PackageDir != l.WorkspaceRoot
+}
+
+var lockfiles = []string{
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lock",
+	"bun.lockb",
+	"deno.lock",
+}
+
+type workspaceConfig struct {
+	Workspaces json.RawMessage `json:"workspaces"`
+	// Workspace lists members in deno.json
+	Workspace json.RawMessage `json:"workspace"`
+}
+
+// located memoizes Locate within a process, keyed by working directory, so
+// the walk up the tree happens once per run
+var located sync.Map
+
+// Locate finds the current package directory and the workspace root above it
+func Locate() (*Location, error) {
+	workingDir, _ := os.Getwd()
+	if location, ok := located.Load(workingDir); ok {
+		return location.(*Location), nil
+	}
+
+	packageDir, err := FindProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	location := &Location{
+		PackageDir:    packageDir,
+		WorkspaceRoot: findWorkspaceRoot(packageDir),
+	}
+	if workingDir != "" {
+		located.Store(workingDir, location)
+	}
+	return location, nil
+}
+
+// FindWorkspaceRoot finds the root of the workspace containing the current package
+func FindWorkspaceRoot() (string, error) {
+	location, err := Locate()
+	if err != nil {
+		return "", err
+	}
+	return location.WorkspaceRoot, nil
+}
+
+// findWorkspaceRoot walks up from packageDir to the nearest ancestor whose
+// workspaces include the package. A package holding a lockfile or declaring
+// workspaces itself is its own root, and so is any other package.
+func findWorkspaceRoot(packageDir string) string {
+	if isWorkspaceRoot(packageDir) {
+		return packageDir
+	}
+
+	currentDir := packageDir
+	depth := 0
+
+	for {
+		parentDir := filepath.Dir(currentDir)
+		depth++
+		if parentDir == currentDir || depth > maxTraverseDepth {
+			break
+		}
+		currentDir = parentDir
+
+		if project.DeclaresPackage(currentDir, packageDir) {
+			return currentDir
+		}
+	}
+
+	return packageDir
+}
+
+// isWorkspaceRoot reports whether the package in dir is a root of its own
+func isWorkspaceRoot(dir string) bool {
+	if fileExists(filepath.Join(dir, "pnpm-workspace.yaml")) {
+		return true
+	}
+	for _, lockfile := range lockfiles {
+		if fileExists(filepath.Join(dir, lockfile)) {
+			return true
+		}
+	}
+	if hasWorkspacesField(filepath.Join(dir, "package.json")) {
+		return true
+	}
+	if path, ok := denoConfigPath(dir); ok {
+		return hasWorkspacesField(path)
+	}
+	return false
+}
+
+func hasWorkspacesField(manifestPath string) bool {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return false
+	}
+
+	var config workspaceConfig
+	if err := json.Unmarshal(jsonc.Strip(data), &config); err != nil {
+		return false
+	}
+
+	return !isEmptyJSON(config.Workspaces) || !isEmptyJSON(config.Workspace)
+}
+
+func isEmptyJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case "", "null", "[]", "{}":
+		return true
+	}
+	return false
+}
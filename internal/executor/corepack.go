@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"pm/internal/config"
+	"pm/internal/detector"
+	"pm/internal/semver"
+	"pm/internal/ui"
+)
+
+// EnsureVersion checks the installed package manager against the version
+// pinned by the packageManager field and the devEngines.packageManager range.
+// It returns the spec commands must run through corepack with, e.g.
+// "pnpm@9.1.0", or "" when the installed binary runs them.
+func EnsureVersion(result *detector.Result) (string, error) {
+	corepack, err := ensurePinnedVersion(result)
+	if err != nil {
+		return "", err
+	}
+	return corepack, ensureDevEngine(result, corepack)
+}
+
+// ensurePinnedVersion compares the version pinned by the packageManager field with
+// the installed binary. On a mismatch it routes commands through corepack,
+// offers to enable corepack, or fails, depending on the corepack policy.
+func ensurePinnedVersion(result *detector.Result) (string, error) {
+	if result.Version == "" {
+		return "", nil
+	}
+
+	cfg := result.Config
+	if cfg.Corepack == config.CorepackOff {
+		return "", nil
+	}
+
+	pm := result.PackageManager
+	pinned, _ := detector.SplitIntegrity(result.Version)
+	spec := pm.Binary() + "@" + result.Version
+
+	installed, err := detector.InstalledVersion(pm, result.RootDir)
+	if err == nil && installed == pinned {
+		return "", nil
+	}
+
+	mismatch := fmt.Sprintf("packageManager requires %s but %s is not installed", spec, pm.Binary())
+	if err == nil {
+		mismatch = fmt.Sprintf("packageManager requires %s but %s %s is installed", spec, pm.Binary(), installed)
+	}
+
+	if cfg.Corepack == config.CorepackError || !isCorepackAvailable() {
+		return "", fmt.Errorf("%s; run `corepack enable` or install the pinned version", mismatch)
+	}
+
+	if cfg.Corepack == config.CorepackPrompt && ui.IsInteractive() {
+		fmt.Fprintln(os.Stderr, mismatch)
+		ok, err := ui.Confirm("Run `corepack enable` now?")
+		if err != nil || !ok {
+			return "", fmt.Errorf("%s", mismatch)
+		}
+		if err := run("corepack", "enable"); err != nil {
+			return "", fmt.Errorf("corepack enable failed: %v", err)
+		}
+		if installed, err := detector.InstalledVersion(pm, result.RootDir); err == nil && installed == pinned {
+			return "", nil
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s; running through corepack\n", mismatch)
+	return spec, nil
+}
+
+// ensureDevEngine enforces the onFail semantics of devEngines.packageManager
+// against the installed version, or the pinned one when corepack runs it
+func ensureDevEngine(result *detector.Result, corepack string) error {
+	engine := result.DevEngine
+	if engine == nil || engine.Version == "" || engine.OnFail == "ignore" {
+		return nil
+	}
+
+	pm := result.PackageManager
+	var installed string
+	if corepack != "" {
+		installed, _ = detector.SplitIntegrity(result.Version)
+	} else {
+		version, err := detector.InstalledVersion(pm, result.RootDir)
+		if err != nil {
+			return devEngineFailure(engine, fmt.Sprintf("devEngines requires %s@%s but %s is not installed", engine.Name, engine.Version, pm.Binary()))
+		}
+		installed = version
+	}
+
+	ok, err := semver.Satisfies(installed, engine.Version)
+	if err != nil {
+		return devEngineFailure(engine, fmt.Sprintf("cannot check devEngines.packageManager: %v", err))
+	}
+	if !ok {
+		return devEngineFailure(engine, fmt.Sprintf("devEngines requires %s@%s but %s %s is installed", engine.Name, engine.Version, pm.Binary(), installed))
+	}
+	return nil
+}
+
+func devEngineFailure(engine *detector.DevEngine, message string) error {
+	if engine.OnFail == "warn" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+		return nil
+	}
+	return fmt.Errorf("%s", message)
+}
+
+func isCorepackAvailable() bool {
+	_, err := exec.LookPath("corepack")
+	return err == nil
+}
+
+// invocation returns the binary and leading arguments used to run the
+// package manager, through corepack when a corepack spec is given
+func invocation(pm detector.PackageManager, corepack string) (string, []string) {
+	if corepack != "" {
+		return "corepack", []string{corepack}
+	}
+	return pmBinary(pm), nil
+}
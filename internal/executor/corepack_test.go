@@ -0,0 +1,254 @@
+package executor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"pm/internal/config"
+	"pm/internal/detector"
+	"pm/internal/translator"
+)
+
+// installFake puts shell scripts printing the given versions on an otherwise
+// empty PATH, standing in for package managers and corepack
+func installFake(t *testing.T, versions map[string]string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	t.Setenv("PM_CACHE_DIR", "off")
+
+	dir := t.TempDir()
+	for name, version := range versions {
+		script := "#!/bin/sh\necho " + version + "\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestEnsureVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed map[string]string
+		policy    string
+		pinned    string
+		corepack  string
+		wantErr   bool
+	}{
+		{
+			name:      "nothing pinned",
+			installed: map[string]string{"pnpm": "8.15.0"},
+		},
+		{
+			name:      "installed version matches",
+			installed: map[string]string{"pnpm": "9.1.0", "corepack": "0.28.0"},
+			pinned:    "9.1.0",
+		},
+		{
+			name:      "mismatch runs through corepack",
+			installed: map[string]string{"pnpm": "8.15.0", "corepack": "0.28.0"},
+			pinned:    "9.1.0",
+			corepack:  "pnpm@9.1.0",
+		},
+		{
+			name:      "missing package manager runs through corepack",
+			installed: map[string]string{"corepack": "0.28.0"},
+			pinned:    "9.1.0",
+			corepack:  "pnpm@9.1.0",
+		},
+		{
+			name:      "mismatch without corepack",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			pinned:    "9.1.0",
+			wantErr:   true,
+		},
+		{
+			name:      "corepack error policy",
+			installed: map[string]string{"pnpm": "8.15.0", "corepack": "0.28.0"},
+			policy:    config.CorepackError,
+			pinned:    "9.1.0",
+			wantErr:   true,
+		},
+		{
+			name:      "corepack off",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			policy:    config.CorepackOff,
+			pinned:    "9.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFake(t, tt.installed)
+			policy := tt.policy
+			if policy == "" {
+				policy = config.CorepackAuto
+			}
+			result := &detector.Result{
+				PackageManager: detector.Pnpm,
+				Version:        tt.pinned,
+				Config:         &config.Config{Corepack: policy},
+			}
+
+			corepack, err := EnsureVersion(result)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("EnsureVersion() = %q, want an error", corepack)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EnsureVersion() error = %v", err)
+			}
+			if corepack != tt.corepack {
+				t.Errorf("EnsureVersion() = %q, want %q", corepack, tt.corepack)
+			}
+		})
+	}
+}
+
+func TestDryRunThroughCorepack(t *testing.T) {
+	t.Setenv("PM_CACHE_DIR", "off")
+	cmd, err := translator.New(detector.Pnpm).Translate(detector.Pnpm, []string{"install"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	DryRun(&out, detector.Pnpm, "pnpm@9.1.0", cmd)
+	if !strings.Contains(out.String(), "$ corepack pnpm@9.1.0 install\n") {
+		t.Errorf("DryRun() = %q, want the command run through corepack", out.String())
+	}
+
+	out.Reset()
+	DryRun(&out, detector.Pnpm, "", cmd)
+	if !strings.Contains(out.String(), "$ pnpm install\n") {
+		t.Errorf("DryRun() = %q, want the installed pnpm", out.String())
+	}
+}
+
+// captureStderr returns what f writes to standard error
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = original }()
+
+	f()
+	w.Close()
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestEnsureDevEngine(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed map[string]string
+		engine    detector.DevEngine
+		pinned    string
+		corepack  string
+		wantErr   bool
+		wantWarn  bool
+	}{
+		{
+			name:      "caret range satisfied",
+			installed: map[string]string{"pnpm": "9.1.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "^9.0.0"},
+		},
+		{
+			name:      "range with spaces satisfied",
+			installed: map[string]string{"pnpm": "9.1.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: ">=9 <10", OnFail: "error"},
+		},
+		{
+			name:      "one of several ranges satisfied",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "^8.10.0 || ^9.0.0"},
+		},
+		{
+			name:      "onFail defaults to error",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "^9.0.0"},
+			wantErr:   true,
+		},
+		{
+			name:      "onFail error",
+			installed: map[string]string{"pnpm": "9.1.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "~9.0.0", OnFail: "error"},
+			wantErr:   true,
+		},
+		{
+			name:      "onFail warn",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "9.x", OnFail: "warn"},
+			wantWarn:  true,
+		},
+		{
+			name:      "onFail ignore",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "^9.0.0", OnFail: "ignore"},
+		},
+		{
+			name:    "not installed",
+			engine:  detector.DevEngine{Name: "pnpm", Version: "^9.0.0"},
+			wantErr: true,
+		},
+		{
+			name:     "not installed with onFail warn",
+			engine:   detector.DevEngine{Name: "pnpm", Version: "^9.0.0", OnFail: "warn"},
+			wantWarn: true,
+		},
+		{
+			name:      "corepack runs the pinned version",
+			installed: map[string]string{"pnpm": "8.15.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "^9.0.0"},
+			pinned:    "9.1.0",
+			corepack:  "pnpm@9.1.0",
+		},
+		{
+			name:      "invalid range",
+			installed: map[string]string{"pnpm": "9.1.0"},
+			engine:    detector.DevEngine{Name: "pnpm", Version: "latest-ish"},
+			wantErr:   true,
+		},
+		{
+			name:      "no range",
+			installed: map[string]string{"pnpm": "9.1.0"},
+			engine:    detector.DevEngine{Name: "pnpm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFake(t, tt.installed)
+			result := &detector.Result{
+				PackageManager: detector.Pnpm,
+				Version:        tt.pinned,
+				DevEngine:      &tt.engine,
+				Config:         &config.Config{Corepack: config.CorepackAuto},
+			}
+
+			var err error
+			stderr := captureStderr(t, func() { err = ensureDevEngine(result, tt.corepack) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ensureDevEngine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if warned := strings.Contains(stderr, "Warning: "); warned != tt.wantWarn {
+				t.Errorf("ensureDevEngine() wrote %q, want a warning: %v", stderr, tt.wantWarn)
+			}
+		})
+	}
+}
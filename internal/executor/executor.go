@@ -2,8 +2,10 @@ package executor
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"pm/internal/detector"
@@ -12,26 +14,90 @@ import (
 	"pm/internal/translator"
 )
 
-// Execute runs a translated command with automatic @types package handling for TypeScript projects
-func Execute(pm detector.PackageManager, cmd *translator.Command) error {
-	// Build the final command
-	finalArgs := append(cmd.Command, cmd.Flags...)
-	finalArgs = append(finalArgs, cmd.Args...)
+// Execute runs a translated command with automatic @types package handling
+// for TypeScript projects, through corepack when a corepack spec is given
+func Execute(pm detector.PackageManager, corepack string, cmd *translator.Command) error {
+	// A failing pre script stops the command, as it does in npm
+	for _, before := range cmd.Before {
+		if err := commandStep(pm, corepack, before).run(); err != nil {
+			return err
+		}
+	}
 
 	// Execute the main command
-	err := run(pmBinary(pm), finalArgs...)
-	if err != nil {
+	if err := commandStep(pm, corepack, cmd).run(); err != nil {
 		return err
 	}
+	for _, after := range cmd.After {
+		if err := commandStep(pm, corepack, after).run(); err != nil {
+			return err
+		}
+	}
 
-	// Handle @types packages if needed
-	if !project.IsTypeScript() {
+	types, ok := typesStep(pm, corepack, cmd)
+	if !ok {
 		return nil
 	}
+	if err := types.run(); err != nil {
+		// Don't fail if @types installation fails
+		fmt.Fprintf(os.Stderr, "Warning: Failed to install @types packages: %v\n", err)
+	}
+	return nil
+}
+
+// DryRun prints what Execute would run for a translated command, and the
+// translation rules that produced it, without running anything
+func DryRun(w io.Writer, pm detector.PackageManager, corepack string, cmd *translator.Command) {
+	for _, rule := range cmd.Rules {
+		fmt.Fprintf(w, "# %s\n", rule)
+	}
+	for _, before := range cmd.Before {
+		fmt.Fprintf(w, "$ %s\n", commandStep(pm, corepack, before))
+	}
+	fmt.Fprintf(w, "$ %s\n", commandStep(pm, corepack, cmd))
+	for _, after := range cmd.After {
+		fmt.Fprintf(w, "$ %s\n", commandStep(pm, corepack, after))
+	}
+	if types, ok := typesStep(pm, corepack, cmd); ok {
+		fmt.Fprintf(w, "$ %s\n", types)
+	}
+}
+
+// commandStep builds the process running a translated command
+func commandStep(pm detector.PackageManager, corepack string, cmd *translator.Command) step {
+	binary, args := invocation(pm, corepack)
+	if cmd.Binary != "" {
+		binary, args = cmd.Binary, nil
+	}
+	args = append(args, cmd.Prefix...)
+	args = append(args, cmd.Command...)
+	args = append(args, cmd.Flags...)
+	args = append(args, cmd.Args...)
+	return step{dir: cmd.Dir, binary: binary, args: args, env: cmd.Env}
+}
+
+// typesStep plans the install of @types packages for the packages a command
+// adds to a TypeScript project, reporting false when there are none
+func typesStep(pm detector.PackageManager, corepack string, cmd *translator.Command) (step, bool) {
+	// Deno resolves npm types on its own
+	if pm == detector.Deno || !addsPackages(pm, cmd) {
+		return step{}, false
+	}
+	location, err := detector.Locate()
+	if err != nil || !project.IsTypeScript(location.PackageDir, location.WorkspaceRoot) {
+		return step{}, false
+	}
 
 	typesToInstall := []string{}
 
-	for _, pkg := range cmd.Args {
+	for _, arg := range cmd.Args {
+		// Only registry packages can have @types counterparts
+		spec, err := translator.ParseSpec(arg)
+		if err != nil || spec.Kind != translator.SpecRegistry {
+			continue
+		}
+		pkg := spec.Name
+
 		// Skip if already a @types package
 		if strings.HasPrefix(pkg, "@types/") {
 			continue
@@ -50,46 +116,35 @@ func Execute(pm detector.PackageManager, cmd *translator.Command) error {
 		}
 	}
 
-	// Install @types packages as dev dependencies
-	if len(typesToInstall) > 0 {
-		devCommand := []string{}
-		devFlag := []string{}
-
-		switch pm {
-		case detector.NPM:
-			devCommand = []string{"install"}
-			devFlag = []string{"--save-dev"}
-		case detector.Yarn, detector.YarnBerry:
-			devCommand = []string{"add"}
-			devFlag = []string{"--dev"}
-		case detector.Pnpm:
-			devCommand = []string{"add"}
-			devFlag = []string{"--save-dev"}
-		case detector.Bun:
-			devCommand = []string{"add"}
-			devFlag = []string{"--dev"}
-		}
-
-		typesArgs := append(devCommand, devFlag...)
-		typesArgs = append(typesArgs, typesToInstall...)
+	if len(typesToInstall) == 0 {
+		return step{}, false
+	}
 
-		err = run(pmBinary(pm), typesArgs...)
-		if err != nil {
-			// Don't fail if @types installation fails
-			fmt.Fprintf(os.Stderr, "Warning: Failed to install @types packages: %v\n", err)
-		}
+	// Install @types packages as dev dependencies
+	args := append([]string{"add", "--save-dev"}, typesToInstall...)
+	types, err := translator.New(pm).Translate(pm, args)
+	if err != nil {
+		return step{}, false
 	}
+	return commandStep(pm, corepack, types), true
+}
 
-	return nil
+// addsPackages reports whether the command adds its arguments as dependencies
+func addsPackages(pm detector.PackageManager, cmd *translator.Command) bool {
+	// Filtered commands add to other packages than the current one
+	if cmd.Binary != "" || len(cmd.Prefix) > 0 || len(cmd.Args) == 0 {
+		return false
+	}
+	return slices.Equal(cmd.Command, translator.Commands.Add[pm])
 }
 
 // Run executes a command with the given package manager and arguments
-func Run(pm detector.PackageManager, command translator.CommandAlias, args ...string) error {
-	return RunWithFlags(pm, command, []FlagAlias{}, args...)
+func Run(pm detector.PackageManager, corepack string, command translator.CommandAlias, args ...string) error {
+	return RunWithFlags(pm, corepack, command, []FlagAlias{}, args...)
 }
 
 // RunWithFlags executes a command with flags using the detected package manager
-func RunWithFlags(pm detector.PackageManager, command translator.CommandAlias, flags []FlagAlias, args ...string) error {
+func RunWithFlags(pm detector.PackageManager, corepack string, command translator.CommandAlias, flags []FlagAlias, args ...string) error {
 	flagArgs := make([]string, len(flags))
 	for i, flag := range flags {
 		switch pm {
@@ -109,12 +164,19 @@ func RunWithFlags(pm detector.PackageManager, command translator.CommandAlias, f
 			flagArgs[i] = flag[detector.Pnpm][0]
 		case detector.Bun:
 			flagArgs[i] = flag[detector.Bun][0]
+		case detector.Deno:
+			flagArgs[i] = flag[detector.Deno][0]
 		default:
 			return fmt.Errorf("unknown package manager: %s", pm)
 		}
 	}
 
-	cmd := exec.Command(pmBinary(pm), append(command[pm], append(flagArgs, args...)...)...)
+	binary, cmdArgs := invocation(pm, corepack)
+	cmdArgs = append(cmdArgs, command[pm]...)
+	cmdArgs = append(cmdArgs, flagArgs...)
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.Command(binary, cmdArgs...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -126,7 +188,13 @@ func RunWithFlags(pm detector.PackageManager, command translator.CommandAlias, f
 type FlagAlias map[detector.PackageManager][]string
 
 func run(command string, args ...string) error {
+	return runIn("", command, args...)
+}
+
+// runIn runs a command in dir, or in the current directory when dir is empty
+func runIn(dir, command string, args ...string) error {
 	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -134,10 +202,5 @@ func run(command string, args ...string) error {
 }
 
 func pmBinary(pm detector.PackageManager) string {
-	switch pm {
-	case detector.YarnBerry:
-		return "yarn"
-	default:
-		return string(pm)
-	}
+	return pm.Binary()
 }
@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"bytes"
+	"testing"
+
+	"pm/internal/detector"
+	"pm/internal/translator"
+)
+
+func TestDryRunPrintsEnv(t *testing.T) {
+	t.Setenv("PM_CACHE_DIR", "off")
+	t.Setenv("PATH", "/usr/bin")
+
+	cmd := &translator.Command{
+		Binary: "sh",
+		Dir:    "/app",
+		Args:   []string{"-c", "vite --open"},
+		Env:    []string{"PATH=/app/node_modules/.bin:/usr/bin", "npm_lifecycle_event=dev", "npm_lifecycle_script=vite --open", "npm_package_version="},
+	}
+
+	var out bytes.Buffer
+	DryRun(&out, detector.Pnpm, "", cmd)
+	want := `$ cd /app && PATH=/app/node_modules/.bin:"$PATH" npm_lifecycle_event=dev npm_lifecycle_script='vite --open' npm_package_version='' sh -c 'vite --open'` + "\n"
+	if out.String() != want {
+		t.Errorf("DryRun() = %s, want %s", out.String(), want)
+	}
+}
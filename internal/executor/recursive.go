@@ -0,0 +1,208 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"pm/internal/detector"
+	"pm/internal/project"
+	"pm/internal/translator"
+)
+
+// RecursiveOptions control RunRecursive
+type RecursiveOptions struct {
+	// Parallel runs packages that do not depend on each other at the same time
+	Parallel bool
+	// IfPresent succeeds even when no package defines the script
+	IfPresent bool
+	// Version is the package manager version the runs are translated for
+	Version string
+	// Hooks decides what happens to the pre and post scripts of each package
+	Hooks translator.HookPolicy
+	// Corepack is the spec the runs go through corepack with, if any
+	Corepack string
+}
+
+// RunRecursive runs a script in every package that defines it, after the
+// workspace packages it depends on
+func RunRecursive(pm detector.PackageManager, packages []project.WorkspacePackage, script string, args []string, opts RecursiveOptions) error {
+	levels, err := scriptLevels(packages, script, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, level := range levels {
+		if !opts.Parallel || len(level) == 1 {
+			for _, pkg := range level {
+				if err := runScriptIn(pm, pkg, script, args, opts, os.Stdout, os.Stderr); err != nil {
+					return fmt.Errorf("%s: %w", pkg.Name, err)
+				}
+			}
+			continue
+		}
+
+		if err := runLevelParallel(pm, level, script, args, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRunRecursive prints what RunRecursive would run, one level of packages
+// that may run at the same time after another
+func DryRunRecursive(w io.Writer, pm detector.PackageManager, packages []project.WorkspacePackage, script string, args []string, opts RecursiveOptions) error {
+	levels, err := scriptLevels(packages, script, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "# pm orders the runs of %q by workspace dependencies\n", script)
+	for i, level := range levels {
+		if opts.Parallel && len(level) > 1 {
+			fmt.Fprintf(w, "# level %d runs in parallel\n", i+1)
+		}
+		for _, pkg := range level {
+			steps, err := scriptSteps(pm, pkg, script, args, opts)
+			if err != nil {
+				return fmt.Errorf("%s: %v", pkg.Name, err)
+			}
+			for _, s := range steps {
+				fmt.Fprintf(w, "$ %s\n", s)
+			}
+		}
+	}
+	return nil
+}
+
+// scriptLevels returns the packages defining script, grouped into levels
+// that only depend on earlier levels
+func scriptLevels(packages []project.WorkspacePackage, script string, opts RecursiveOptions) ([][]project.WorkspacePackage, error) {
+	// Order every package, so that packages without the script still order
+	// the packages around them
+	levels, err := project.Levels(packages)
+	if err != nil {
+		return nil, err
+	}
+
+	var withScript [][]project.WorkspacePackage
+	for _, level := range levels {
+		var kept []project.WorkspacePackage
+		for _, pkg := range level {
+			if pkg.Manifest == nil {
+				continue
+			}
+			if _, ok := pkg.Manifest.Scripts[script]; ok {
+				kept = append(kept, pkg)
+			}
+		}
+		if len(kept) > 0 {
+			withScript = append(withScript, kept)
+		}
+	}
+	if len(withScript) == 0 && !opts.IfPresent {
+		return nil, fmt.Errorf("no workspace package defines a %q script", script)
+	}
+	return withScript, nil
+}
+
+// runLevelParallel runs the script in every package of a level at once,
+// prefixing each output line with the package name
+func runLevelParallel(pm detector.PackageManager, level []project.WorkspacePackage, script string, args []string, opts RecursiveOptions) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := make([]error, len(level))
+
+	for i, pkg := range level {
+		wg.Add(1)
+		go func(i int, pkg project.WorkspacePackage) {
+			defer wg.Done()
+			stdout := &prefixWriter{prefix: pkg.Name + ": ", out: os.Stdout, mu: &mu}
+			stderr := &prefixWriter{prefix: pkg.Name + ": ", out: os.Stderr, mu: &mu}
+			errs[i] = runScriptIn(pm, pkg, script, args, opts, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+		}(i, pkg)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("%s: %w", level[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// scriptSteps builds the processes running script in a package, with the
+// pre and post scripts the hook policy asks for
+func scriptSteps(pm detector.PackageManager, pkg project.WorkspacePackage, script string, args []string, opts RecursiveOptions) ([]step, error) {
+	tr := translator.New(pm,
+		translator.WithVersion(opts.Version),
+		translator.WithManifest(pkg.Manifest),
+		translator.WithPackageDir(pkg.Dir),
+		translator.WithHooks(opts.Hooks),
+	)
+	cmd, err := tr.Script(script, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []step
+	for _, c := range append(append(slices.Clone(cmd.Before), cmd), cmd.After...) {
+		s := commandStep(pm, opts.Corepack, c)
+		s.dir = pkg.Dir
+		steps = append(steps, s)
+	}
+	return steps, nil
+}
+
+func runScriptIn(pm detector.PackageManager, pkg project.WorkspacePackage, script string, args []string, opts RecursiveOptions, stdout, stderr io.Writer) error {
+	steps, err := scriptSteps(pm, pkg, script, args, opts)
+	if err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if err := s.command(stdout, stderr).Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixWriter writes complete lines with a prefix, so concurrent output stays readable
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+		w.mu.Unlock()
+	}
+}
+
+// Flush writes a trailing line that did not end with a newline
+func (w *prefixWriter) Flush() {
+	if w.buf.Len() == 0 {
+		return
+	}
+	w.mu.Lock()
+	fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf.Bytes())
+	w.mu.Unlock()
+	w.buf.Reset()
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"pm/internal/shell"
+)
+
+// step is a single process run by pm
+type step struct {
+	// dir is the working directory, empty for the current one
+	dir    string
+	binary string
+	args   []string
+	// env is added to pm's own environment
+	env []string
+}
+
+func (s step) run() error {
+	cmd := s.command(os.Stdout, os.Stderr)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// command prepares the process with its output going to stdout and stderr
+func (s step) command(stdout, stderr io.Writer) *exec.Cmd {
+	cmd := exec.Command(s.binary, s.args...)
+	setCommandLine(cmd, s)
+	cmd.Dir = s.dir
+	if len(s.env) > 0 {
+		cmd.Env = append(os.Environ(), s.env...)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd
+}
+
+// String spells the step as a shell command line that can be pasted,
+// with the environment it adds
+func (s step) String() string {
+	line := shell.Join(append([]string{s.binary}, s.args...))
+	if len(s.env) > 0 {
+		assignments := make([]string, len(s.env))
+		for i, variable := range s.env {
+			assignments[i] = assignment(variable)
+		}
+		line = strings.Join(assignments, " ") + " " + line
+	}
+	if s.dir != "" {
+		line = "cd " + shell.Quote(s.dir) + " && " + line
+	}
+	return line
+}
+
+// assignment spells a variable of the environment as a shell assignment,
+// referring to the current value it extends instead of repeating it
+func assignment(variable string) string {
+	key, value, _ := strings.Cut(variable, "=")
+	if current := os.Getenv(key); current != "" && value != current && strings.HasSuffix(value, current) {
+		return key + "=" + shell.Quote(strings.TrimSuffix(value, current)) + `"$` + key + `"`
+	}
+	return key + "=" + shell.Quote(value)
+}
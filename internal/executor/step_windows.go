@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package executor
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// setCommandLine hands the script line of cmd.exe over as written: cmd does
+// not understand the quoting Go applies to arguments, and /s makes it strip
+// only the quotes around the line
+func setCommandLine(cmd *exec.Cmd, s step) {
+	if s.binary != "cmd" || len(s.args) == 0 {
+		return
+	}
+	last := len(s.args) - 1
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: "cmd " + strings.Join(s.args[:last], " ") + ` "` + s.args[last] + `"`,
+	}
+}
@@ -0,0 +1,71 @@
+package jsonc
+
+// Strip removes comments and trailing commas from JSONC data (as used by
+// deno.jsonc and tsconfig.json) so it can be decoded with encoding/json.
+func Strip(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ',' && nextSignificant(data, i+1) != 0 && (nextSignificant(data, i+1) == '}' || nextSignificant(data, i+1) == ']'):
+			// Drop trailing commas
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// nextSignificant returns the next byte after whitespace and comments, or 0
+func nextSignificant(data []byte, start int) byte {
+	for i := start; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		default:
+			return c
+		}
+	}
+	return 0
+}
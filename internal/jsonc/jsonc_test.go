@@ -0,0 +1,32 @@
+package jsonc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	input := `{
+		// line comment
+		"tasks": {
+			"dev": "deno run -A main.ts", /* block comment */
+			"url": "https://example.com/a//b",
+		},
+		"imports": ["x", "y",],
+	}`
+
+	var decoded struct {
+		Tasks   map[string]string `json:"tasks"`
+		Imports []string          `json:"imports"`
+	}
+	if err := json.Unmarshal(Strip([]byte(input)), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v\n%s", err, Strip([]byte(input)))
+	}
+
+	if decoded.Tasks["url"] != "https://example.com/a//b" {
+		t.Errorf("url = %q, want comment markers inside strings preserved", decoded.Tasks["url"])
+	}
+	if len(decoded.Imports) != 2 {
+		t.Errorf("Imports = %v, want 2 entries", decoded.Imports)
+	}
+}
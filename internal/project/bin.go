@@ -0,0 +1,78 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BinDirs returns every node_modules/.bin from packageDir up to rootDir,
+// nearest first, the way package managers put them on PATH
+func BinDirs(packageDir, rootDir string) []string {
+	var dirs []string
+
+	dir := packageDir
+	for {
+		dirs = append(dirs, filepath.Join(dir, "node_modules", ".bin"))
+		if dir == rootDir || rootDir == "" {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return dirs
+}
+
+// FindBinary returns the path of a package binary in the first of dirs providing it
+func FindBinary(dirs []string, name string) (string, bool) {
+	for _, dir := range dirs {
+		for _, candidate := range binaryNames(name) {
+			path := filepath.Join(dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ListBinaries returns the names of the package binaries in dirs
+func ListBinaries(dirs []string) []string {
+	seen := map[string]bool{}
+	var names []string
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if runtime.GOOS == "windows" {
+				ext := filepath.Ext(name)
+				if ext != ".cmd" && ext != ".exe" {
+					continue
+				}
+				name = strings.TrimSuffix(name, ext)
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+func binaryNames(name string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{name + ".cmd", name + ".exe", name}
+	}
+	return []string{name}
+}
@@ -0,0 +1,101 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pm/internal/cache"
+)
+
+const (
+	manifestBucket   = "manifests"
+	typeScriptBucket = "typescript"
+)
+
+// cachedPackageJSON is the stored form of PackageJSON; scripts are kept as a
+// list because the scripts map loses their order
+type cachedPackageJSON struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	Scripts              []Script          `json:"scripts"`
+}
+
+// ReadPackageJSON parses the package.json at path. Parsed files are cached on
+// disk until the file changes.
+func ReadPackageJSON(path string) (*PackageJSON, error) {
+	var cached cachedPackageJSON
+	if cache.Load(manifestBucket, path, &cached) {
+		pkg := &PackageJSON{
+			Name:                 cached.Name,
+			Version:              cached.Version,
+			Dependencies:         cached.Dependencies,
+			DevDependencies:      cached.DevDependencies,
+			OptionalDependencies: cached.OptionalDependencies,
+			PeerDependencies:     cached.PeerDependencies,
+			Scripts:              make(map[string]string, len(cached.Scripts)),
+			OrderedScripts:       cached.Scripts,
+		}
+		for _, script := range cached.Scripts {
+			pkg.Scripts[script.Name] = script.Command
+		}
+		return pkg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read package.json: %v", err)
+	}
+
+	var pkg PackageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("cannot parse package.json: %v", err)
+	}
+
+	_ = cache.Store(manifestBucket, path, []string{path}, cachedPackageJSON{
+		Name:                 pkg.Name,
+		Version:              pkg.Version,
+		Dependencies:         pkg.Dependencies,
+		DevDependencies:      pkg.DevDependencies,
+		OptionalDependencies: pkg.OptionalDependencies,
+		PeerDependencies:     pkg.PeerDependencies,
+		Scripts:              pkg.OrderedScripts,
+	})
+	return &pkg, nil
+}
+
+// IsTypeScript checks if the package in packageDir, or the workspace root
+// above it, uses TypeScript. The answer is cached on disk until a
+// tsconfig.json or package.json it depends on changes.
+func IsTypeScript(packageDir, rootDir string) bool {
+	dirs := []string{packageDir}
+	if rootDir != packageDir {
+		dirs = append(dirs, rootDir)
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		paths = append(paths, filepath.Join(dir, "tsconfig.json"), filepath.Join(dir, "package.json"))
+	}
+
+	key := packageDir + "@" + rootDir
+	var typeScript bool
+	if cache.Load(typeScriptBucket, key, &typeScript) {
+		return typeScript
+	}
+
+	for _, dir := range dirs {
+		if usesTypeScript(dir) {
+			typeScript = true
+			break
+		}
+	}
+
+	_ = cache.Store(typeScriptBucket, key, paths, typeScript)
+	return typeScript
+}
@@ -0,0 +1,101 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"pm/internal/jsonc"
+)
+
+// DenoConfig represents a deno.json or deno.jsonc file with ordered tasks
+type DenoConfig struct {
+	OrderedTasks []Script
+}
+
+type denoTask struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// ReadDenoConfig reads and parses a deno.json or deno.jsonc file
+func ReadDenoConfig(path string) (*DenoConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config DenoConfig
+	if err := json.Unmarshal(jsonc.Strip(data), &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling to preserve task order
+func (d *DenoConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	tasksData, exists := raw["tasks"]
+	if !exists {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(tasksData))
+
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("expected '{' but got %v", token)
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("expected string key but got %v", token)
+		}
+
+		// Tasks are either a command string or an object with a command
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		var command string
+		if err := json.Unmarshal(value, &command); err != nil {
+			var task denoTask
+			if err := json.Unmarshal(value, &task); err != nil {
+				return fmt.Errorf("invalid task %q: %v", key, err)
+			}
+			command = task.Command
+			if command == "" {
+				command = task.Description
+			}
+		}
+
+		d.OrderedTasks = append(d.OrderedTasks, Script{
+			Name:    key,
+			Command: command,
+		})
+	}
+
+	token, err = dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('}') {
+		return fmt.Errorf("expected '}' but got %v", token)
+	}
+
+	return nil
+}
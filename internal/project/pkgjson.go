@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
+	"unicode"
 )
 
 // PackageJSON represents a package.json file with ordered scripts
 type PackageJSON struct {
-	Dependencies    map[string]string `json:"dependencies"`
-	DevDependencies map[string]string `json:"devDependencies"`
-	Scripts         map[string]string `json:"scripts"`
-	OrderedScripts  []Script
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	Scripts              map[string]string `json:"scripts"`
+	OrderedScripts       []Script
 }
 
 // Script represents a npm script with its name and command
@@ -20,6 +27,20 @@ type Script struct {
 	Command string
 }
 
+// Hooks returns the pre<script> and post<script> lifecycle scripts that
+// package managers may run around script, nil when they are not defined
+func (p *PackageJSON) Hooks(script string) (pre, post *Script) {
+	for i := range p.OrderedScripts {
+		switch p.OrderedScripts[i].Name {
+		case "pre" + script:
+			pre = &p.OrderedScripts[i]
+		case "post" + script:
+			post = &p.OrderedScripts[i]
+		}
+	}
+	return pre, post
+}
+
 // UnmarshalJSON implements custom JSON unmarshaling to preserve script order
 func (p *PackageJSON) UnmarshalJSON(data []byte) error {
 	// First, unmarshal dependencies and devDependencies normally
@@ -86,3 +107,71 @@ func (p *PackageJSON) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+var packageManagerFieldPattern = regexp.MustCompile(`("packageManager"\s*:\s*)"[^"]*"`)
+var indentPattern = regexp.MustCompile(`\n([ \t]+)"`)
+
+// SetPackageManager writes value, e.g. "pnpm@9.1.0", to the packageManager
+// field of the package.json at path. The rest of the file is left untouched.
+func SetPackageManager(path, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var updated []byte
+	if _, exists := fields["packageManager"]; exists {
+		loc := packageManagerFieldPattern.FindSubmatchIndex(data)
+		if loc == nil {
+			return fmt.Errorf("cannot update packageManager in %s: not a string", path)
+		}
+		updated = append(append(append([]byte{}, data[:loc[3]]...), quoted...), data[loc[1]:]...)
+	} else {
+		updated = insertField(data, `"packageManager": `+string(quoted))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, updated, info.Mode().Perm())
+}
+
+// insertField adds a field at the end of the top level object, matching the
+// indentation and line endings already used by the file
+func insertField(data []byte, field string) []byte {
+	end := bytes.LastIndexByte(data, '}')
+	last := bytes.LastIndexFunc(data[:end], func(r rune) bool {
+		return !unicode.IsSpace(r)
+	})
+
+	lineEnding := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		lineEnding = "\r\n"
+	}
+	indent := "  "
+	if match := indentPattern.FindSubmatch(data); match != nil {
+		indent = string(match[1])
+	}
+
+	separator := ","
+	if data[last] == '{' {
+		separator = ""
+	}
+
+	var buf bytes.Buffer
+	buf.Write(data[:last+1])
+	buf.WriteString(separator + lineEnding + indent + field + lineEnding)
+	buf.Write(data[end:])
+	return buf.Bytes()
+}
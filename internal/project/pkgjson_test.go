@@ -2,9 +2,17 @@ package project
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	// Keep tests away from the user's cache; cache tests opt back in
+	os.Setenv("PM_CACHE_DIR", "off")
+	os.Exit(m.Run())
+}
+
 func TestPackageJSONUnmarshal(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -176,3 +184,147 @@ func TestPackageJSONScriptOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestPackageJSONHooks(t *testing.T) {
+	jsonData := `{
+		"scripts": {
+			"prebuild": "rimraf dist",
+			"build": "tsc",
+			"postbuild": "cp README.md dist",
+			"pretest": "eslint ."
+		}
+	}`
+
+	var pkg PackageJSON
+	if err := json.Unmarshal([]byte(jsonData), &pkg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	pre, post := pkg.Hooks("build")
+	if pre == nil || pre.Command != "rimraf dist" {
+		t.Errorf("Hooks(build) pre = %v, want prebuild", pre)
+	}
+	if post == nil || post.Command != "cp README.md dist" {
+		t.Errorf("Hooks(build) post = %v, want postbuild", post)
+	}
+
+	pre, post = pkg.Hooks("test")
+	if pre == nil || post != nil {
+		t.Errorf("Hooks(test) = %v, %v, want only pretest", pre, post)
+	}
+
+	if pre, post := pkg.Hooks("dev"); pre != nil || post != nil {
+		t.Errorf("Hooks(dev) = %v, %v, want none", pre, post)
+	}
+}
+
+func TestDenoConfigTasks(t *testing.T) {
+	jsonData := `{
+		"tasks": {
+			"dev": "deno run --watch main.ts",
+			"build": {
+				"description": "Build the app",
+				"command": "deno compile main.ts"
+			}
+		}
+	}`
+
+	var config DenoConfig
+	if err := json.Unmarshal([]byte(jsonData), &config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if len(config.OrderedTasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(config.OrderedTasks))
+	}
+	if config.OrderedTasks[0].Name != "dev" || config.OrderedTasks[0].Command != "deno run --watch main.ts" {
+		t.Errorf("First task = %+v, want dev", config.OrderedTasks[0])
+	}
+	if config.OrderedTasks[1].Command != "deno compile main.ts" {
+		t.Errorf("Second task command = %q, want the object's command", config.OrderedTasks[1].Command)
+	}
+}
+
+func TestSetPackageManager(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		want     string
+	}{
+		{
+			name:     "appends the field with the file's indentation",
+			original: "{\n    \"name\": \"app\",\n    \"version\": \"1.0.0\"\n}\n",
+			want:     "{\n    \"name\": \"app\",\n    \"version\": \"1.0.0\",\n    \"packageManager\": \"pnpm@9.1.0\"\n}\n",
+		},
+		{
+			name:     "replaces an existing field in place",
+			original: "{\n  \"packageManager\": \"yarn@1.22.19\",\n  \"name\": \"app\"\n}\n",
+			want:     "{\n  \"packageManager\": \"pnpm@9.1.0\",\n  \"name\": \"app\"\n}\n",
+		},
+		{
+			name:     "keeps CRLF line endings",
+			original: "{\r\n\t\"name\": \"app\"\r\n}\r\n",
+			want:     "{\r\n\t\"name\": \"app\",\r\n\t\"packageManager\": \"pnpm@9.1.0\"\r\n}\r\n",
+		},
+		{
+			name:     "fills an empty object",
+			original: "{}\n",
+			want:     "{\n  \"packageManager\": \"pnpm@9.1.0\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "package.json")
+			if err := os.WriteFile(path, []byte(tt.original), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := SetPackageManager(path, "pnpm@9.1.0"); err != nil {
+				t.Fatalf("SetPackageManager() error = %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("package.json = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPackageJSONCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PM_CACHE_DIR", filepath.Join(tmpDir, "cache"))
+
+	path := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(path, []byte(`{"scripts":{"dev":"vite","build":"tsc"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		pkg, err := ReadPackageJSON(path)
+		if err != nil {
+			t.Fatalf("ReadPackageJSON() error = %v", err)
+		}
+		if len(pkg.OrderedScripts) != 2 || pkg.OrderedScripts[0].Name != "dev" || pkg.Scripts["build"] != "tsc" {
+			t.Errorf("read %d: scripts = %+v, want dev then build", i, pkg.OrderedScripts)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(`{"scripts":{"test":"jest"},"devDependencies":{"typescript":"^5.0.0"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := ReadPackageJSON(path)
+	if err != nil {
+		t.Fatalf("ReadPackageJSON() error = %v", err)
+	}
+	if len(pkg.OrderedScripts) != 1 || pkg.OrderedScripts[0].Name != "test" {
+		t.Errorf("scripts after change = %+v, want only test", pkg.OrderedScripts)
+	}
+	if _, ok := pkg.DevDependencies["typescript"]; !ok {
+		t.Errorf("DevDependencies after change = %v, want typescript", pkg.DevDependencies)
+	}
+}
@@ -0,0 +1,197 @@
+package project
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Select resolves workspace filters to packages, in workspace order. A filter
+// is a package name or name glob ("@app/*"), a directory glob ("./apps/*" or
+// "{apps/web}", relative to cwd), optionally with pnpm-style dependency
+// selectors: "foo..." adds its dependencies, "...foo" its dependents, and
+// "^" leaves out the matched package itself ("foo^...", "...^foo").
+func (w *Workspace) Select(filters []string, cwd string) ([]WorkspacePackage, error) {
+	selected := map[string]bool{}
+
+	for _, filter := range filters {
+		selector := parseSelector(filter)
+
+		matched := w.match(selector.pattern, cwd)
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no workspace package matches %q", filter)
+		}
+
+		for _, dir := range matched {
+			if selector.includeSelf {
+				selected[dir] = true
+			}
+			if selector.dependencies {
+				w.walk(dir, w.dependenciesOf, selected)
+			}
+			if selector.dependents {
+				w.walk(dir, w.dependentsOf, selected)
+			}
+		}
+	}
+
+	var packages []WorkspacePackage
+	for _, pkg := range w.Packages {
+		if selected[pkg.Dir] {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+type selector struct {
+	pattern      string
+	dependencies bool
+	dependents   bool
+	includeSelf  bool
+}
+
+func parseSelector(filter string) selector {
+	s := selector{pattern: filter, includeSelf: true}
+
+	if strings.HasSuffix(s.pattern, "...") {
+		s.dependencies = true
+		s.pattern = strings.TrimSuffix(s.pattern, "...")
+		if strings.HasSuffix(s.pattern, "^") {
+			s.includeSelf = false
+			s.pattern = strings.TrimSuffix(s.pattern, "^")
+		}
+	}
+	if strings.HasPrefix(s.pattern, "...") {
+		s.dependents = true
+		s.pattern = strings.TrimPrefix(s.pattern, "...")
+		if strings.HasPrefix(s.pattern, "^") {
+			s.includeSelf = false
+			s.pattern = strings.TrimPrefix(s.pattern, "^")
+		}
+	}
+
+	return s
+}
+
+// match returns the directories of the packages a name or directory pattern selects
+func (w *Workspace) match(pattern, cwd string) []string {
+	var matched []string
+
+	dirPattern, isDir := directoryPattern(pattern)
+	if isDir {
+		if !filepath.IsAbs(dirPattern) {
+			dirPattern = filepath.Join(cwd, dirPattern)
+		}
+		dirPattern = filepath.ToSlash(filepath.Clean(dirPattern))
+	}
+
+	for _, pkg := range w.Packages {
+		var ok bool
+		if isDir {
+			ok = matchDir(dirPattern, filepath.ToSlash(pkg.Dir))
+		} else {
+			ok, _ = path.Match(pattern, pkg.Name)
+		}
+		if ok {
+			matched = append(matched, pkg.Dir)
+		}
+	}
+	return matched
+}
+
+// directoryPattern recognizes "{dir}" and paths starting with "." or "/"
+func directoryPattern(pattern string) (string, bool) {
+	if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}") {
+		return strings.TrimSuffix(strings.TrimPrefix(pattern, "{"), "}"), true
+	}
+	if strings.HasPrefix(pattern, ".") || filepath.IsAbs(pattern) {
+		return pattern, true
+	}
+	return "", false
+}
+
+// matchDir matches a slash separated directory against a glob where "**"
+// spans any number of directories
+func matchDir(pattern, dir string) bool {
+	patternParts := strings.Split(pattern, "/")
+	dirParts := strings.Split(dir, "/")
+
+	var matchFrom func(p, d int) bool
+	matchFrom = func(p, d int) bool {
+		if p == len(patternParts) {
+			return d == len(dirParts)
+		}
+		if patternParts[p] == "**" {
+			for next := d; next <= len(dirParts); next++ {
+				if matchFrom(p+1, next) {
+					return true
+				}
+			}
+			return false
+		}
+		if d == len(dirParts) {
+			return false
+		}
+		if ok, _ := path.Match(patternParts[p], dirParts[d]); !ok {
+			return false
+		}
+		return matchFrom(p+1, d+1)
+	}
+
+	return matchFrom(0, 0)
+}
+
+// walk adds every package reachable from dir through next, transitively
+func (w *Workspace) walk(dir string, next func(dir string) []string, selected map[string]bool) {
+	queue := next(dir)
+	visited := map[string]bool{dir: true}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		selected[current] = true
+		queue = append(queue, next(current)...)
+	}
+}
+
+// dependenciesOf returns the workspace packages the package in dir depends on
+func (w *Workspace) dependenciesOf(dir string) []string {
+	var dirs []string
+	for _, pkg := range w.Packages {
+		if pkg.Dir != dir {
+			continue
+		}
+		for _, name := range pkg.Dependencies {
+			if dep, ok := w.Find(name); ok {
+				dirs = append(dirs, dep.Dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// dependentsOf returns the workspace packages depending on the package in dir
+func (w *Workspace) dependentsOf(dir string) []string {
+	var name string
+	for _, pkg := range w.Packages {
+		if pkg.Dir == dir {
+			name = pkg.Name
+		}
+	}
+
+	var dirs []string
+	for _, pkg := range w.Packages {
+		for _, dep := range pkg.Dependencies {
+			if dep == name && name != "" {
+				dirs = append(dirs, pkg.Dir)
+				break
+			}
+		}
+	}
+	return dirs
+}
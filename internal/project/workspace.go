@@ -0,0 +1,376 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"pm/internal/jsonc"
+)
+
+// WorkspacePackage is a package of a monorepo
+type WorkspacePackage struct {
+	Name string
+	Dir  string
+	// Dependencies names every dependency, dev, optional and peer dependency
+	Dependencies []string
+	Manifest     *PackageJSON
+}
+
+// Workspace lists the packages of a monorepo in a stable order
+type Workspace struct {
+	Root     string
+	Packages []WorkspacePackage
+}
+
+type workspacesField struct {
+	Workspaces json.RawMessage `json:"workspaces"`
+	// Workspace lists members in deno.json
+	Workspace []string `json:"workspace"`
+}
+
+// LoadWorkspace reads the member packages declared in pnpm-workspace.yaml,
+// the workspaces field of package.json or the workspace field of deno.json
+func LoadWorkspace(rootDir string) (*Workspace, error) {
+	patterns, err := workspacePatterns(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("%s does not declare any workspace packages", rootDir)
+	}
+
+	var include, exclude []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			exclude = append(exclude, expandPattern(rootDir, strings.TrimPrefix(pattern, "!"))...)
+		} else {
+			include = append(include, expandPattern(rootDir, pattern)...)
+		}
+	}
+
+	workspace := &Workspace{Root: rootDir}
+	seen := map[string]bool{}
+	for _, dir := range include {
+		if seen[dir] || containsPath(exclude, dir) {
+			continue
+		}
+		seen[dir] = true
+
+		pkg, err := ReadPackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		workspace.Packages = append(workspace.Packages, WorkspacePackage{
+			Name:         pkg.Name,
+			Dir:          dir,
+			Dependencies: dependencyNames(pkg),
+			Manifest:     pkg,
+		})
+	}
+
+	sort.SliceStable(workspace.Packages, func(i, j int) bool {
+		return workspace.Packages[i].Dir < workspace.Packages[j].Dir
+	})
+	return workspace, nil
+}
+
+// Find returns the workspace package with the given name
+func (w *Workspace) Find(name string) (*WorkspacePackage, bool) {
+	for i := range w.Packages {
+		if w.Packages[i].Name == name {
+			return &w.Packages[i], true
+		}
+	}
+	return nil, false
+}
+
+// DeclaresPackage reports whether the workspace at rootDir declares the
+// package in dir, a directory below it, as one of its members
+func DeclaresPackage(rootDir, dir string) bool {
+	rel, err := filepath.Rel(rootDir, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	if slices.Contains(segments, "node_modules") {
+		return false
+	}
+
+	patterns, err := workspacePatterns(rootDir)
+	if err != nil {
+		return false
+	}
+	declared := false
+	for _, pattern := range patterns {
+		if excluded, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPattern(excluded, segments) {
+				return false
+			}
+		} else if matchPattern(pattern, segments) {
+			declared = true
+		}
+	}
+	return declared
+}
+
+func workspacePatterns(rootDir string) ([]string, error) {
+	if patterns, err := readPnpmWorkspace(filepath.Join(rootDir, "pnpm-workspace.yaml")); err == nil {
+		return patterns, nil
+	}
+
+	for _, name := range []string{"package.json", "deno.json", "deno.jsonc"} {
+		data, err := os.ReadFile(filepath.Join(rootDir, name))
+		if err != nil {
+			continue
+		}
+
+		var field workspacesField
+		if err := json.Unmarshal(jsonc.Strip(data), &field); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %v", name, err)
+		}
+		if len(field.Workspace) > 0 {
+			return field.Workspace, nil
+		}
+		if len(field.Workspaces) == 0 {
+			continue
+		}
+
+		// workspaces is either a list or {"packages": [...]} (yarn classic)
+		var patterns []string
+		if err := json.Unmarshal(field.Workspaces, &patterns); err == nil {
+			return patterns, nil
+		}
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(field.Workspaces, &object); err != nil {
+			return nil, fmt.Errorf("cannot parse workspaces in %s: %v", name, err)
+		}
+		return object.Packages, nil
+	}
+
+	return nil, nil
+}
+
+// readPnpmWorkspace reads the packages list of pnpm-workspace.yaml, either
+// as the block list pnpm documents or as a flow list such as ['apps/*', 'libs/*']
+func readPnpmWorkspace(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	var flow strings.Builder
+	inPackages, inFlow := false, false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, " #"); comment >= 0 {
+			line = line[:comment]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// A flow list may span lines until its closing bracket
+		if inFlow {
+			flow.WriteString(trimmed)
+			inFlow = !strings.Contains(trimmed, "]")
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			key, value, _ := strings.Cut(trimmed, ":")
+			inPackages = key == "packages"
+			if value = strings.TrimSpace(value); inPackages && strings.HasPrefix(value, "[") {
+				flow.WriteString(value)
+				inFlow = !strings.Contains(value, "]")
+			}
+			continue
+		}
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			patterns = append(patterns, strings.Trim(pattern, `"'`))
+		}
+	}
+
+	list, _, _ := strings.Cut(strings.TrimPrefix(flow.String(), "["), "]")
+	for _, item := range strings.Split(list, ",") {
+		if pattern := strings.Trim(strings.TrimSpace(item), `"'`); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// matchPattern reports whether the segments of a path below the workspace
+// root match a workspace glob, the way expandPattern would find it
+func matchPattern(pattern string, segments []string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/")
+	return matchSegments(strings.Split(pattern, "/"), segments)
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := range len(segments) + 1 {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// expandPattern returns the directories below rootDir matching a workspace
+// glob such as "packages/*" or "apps/**"
+func expandPattern(rootDir, pattern string) []string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/")
+	dirs := []string{rootDir}
+
+	for _, segment := range strings.Split(pattern, "/") {
+		var next []string
+		for _, dir := range dirs {
+			switch {
+			case segment == "**":
+				next = append(next, descendants(dir)...)
+			case strings.ContainsAny(segment, "*?["):
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					continue
+				}
+				for _, entry := range entries {
+					if ok, _ := path.Match(segment, entry.Name()); ok && entry.IsDir() && entry.Name() != "node_modules" {
+						next = append(next, filepath.Join(dir, entry.Name()))
+					}
+				}
+			default:
+				candidate := filepath.Join(dir, segment)
+				if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+					next = append(next, candidate)
+				}
+			}
+		}
+		dirs = next
+	}
+
+	return dirs
+}
+
+// descendants returns dir and every directory below it outside node_modules
+func descendants(dir string) []string {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == "node_modules" || (path != dir && strings.HasPrefix(entry.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs
+}
+
+func dependencyNames(pkg *PackageJSON) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies, pkg.PeerDependencies} {
+		for name := range deps {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsPath(paths []string, target string) bool {
+	for _, p := range paths {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Levels orders packages so that every package comes after the workspace
+// packages it depends on. Packages within a level do not depend on each
+// other and may run concurrently.
+func Levels(packages []WorkspacePackage) ([][]WorkspacePackage, error) {
+	index := map[string]int{}
+	for i, pkg := range packages {
+		if pkg.Name != "" {
+			index[pkg.Name] = i
+		}
+	}
+
+	// pending counts the unfinished dependencies of each package
+	pending := make([]int, len(packages))
+	dependents := make([][]int, len(packages))
+	for i, pkg := range packages {
+		for _, dep := range pkg.Dependencies {
+			if j, ok := index[dep]; ok && j != i {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	var levels [][]WorkspacePackage
+	var ready []int
+	for i := range packages {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	done := 0
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		level := make([]WorkspacePackage, len(ready))
+		var next []int
+		for k, i := range ready {
+			level[k] = packages[i]
+			for _, dependent := range dependents[i] {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		levels = append(levels, level)
+		done += len(ready)
+		ready = next
+	}
+
+	if done < len(packages) {
+		var cycle []string
+		for i, count := range pending {
+			if count > 0 {
+				cycle = append(cycle, packages[i].Name)
+			}
+		}
+		return nil, fmt.Errorf("dependency cycle between workspace packages: %s", strings.Join(cycle, ", "))
+	}
+	return levels, nil
+}
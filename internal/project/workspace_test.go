@@ -0,0 +1,227 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWorkspace(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "pnpm-workspace.yaml",
+			files: map[string]string{
+				"package.json":        `{"name":"root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n  - \"!packages/ignored\"\n",
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "pnpm-workspace.yaml flow list",
+			files: map[string]string{
+				"package.json":        `{"name":"root"}`,
+				"pnpm-workspace.yaml": "packages: ['packages/*', \"!packages/ignored\"] # members\n",
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "pnpm-workspace.yaml flow list over several lines",
+			files: map[string]string{
+				"package.json":        `{"name":"root"}`,
+				"pnpm-workspace.yaml": "packages: [\n  apps/*,\n  packages/a\n]\ncatalog:\n  react: ^18\n",
+			},
+			want: []string{"web", "a"},
+		},
+		{
+			name: "workspaces list",
+			files: map[string]string{
+				"package.json": `{"name":"root","workspaces":["packages/*","apps/**"]}`,
+			},
+			want: []string{"web", "a", "b", "ignored"},
+		},
+		{
+			name: "yarn classic workspaces object",
+			files: map[string]string{
+				"package.json": `{"name":"root","workspaces":{"packages":["packages/a"]}}`,
+			},
+			want: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeWorkspace(t, root, map[string]string{
+				"packages/a/package.json":       `{"name":"a"}`,
+				"packages/b/package.json":       `{"name":"b","dependencies":{"a":"workspace:*"}}`,
+				"packages/ignored/package.json": `{"name":"ignored"}`,
+				"apps/web/package.json":         `{"name":"web"}`,
+			})
+			writeWorkspace(t, root, tt.files)
+
+			workspace, err := LoadWorkspace(root)
+			if err != nil {
+				t.Fatalf("LoadWorkspace() error = %v", err)
+			}
+
+			var got []string
+			for _, pkg := range workspace.Packages {
+				got = append(got, pkg.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Packages = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Packages = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeclaresPackage(t *testing.T) {
+	tests := []struct {
+		name       string
+		workspaces string
+		dir        string
+		want       bool
+	}{
+		{name: "star", workspaces: `["packages/*"]`, dir: "packages/a", want: true},
+		{name: "star is one level", workspaces: `["packages/*"]`, dir: "packages/a/nested"},
+		{name: "globstar", workspaces: `["apps/**"]`, dir: "apps/web/site", want: true},
+		{name: "exact path", workspaces: `["./packages/a/"]`, dir: "packages/a", want: true},
+		{name: "excluded", workspaces: `["packages/*","!packages/a"]`, dir: "packages/a"},
+		{name: "other packages", workspaces: `["apps/*"]`, dir: "packages/a"},
+		{name: "node_modules", workspaces: `["**"]`, dir: "node_modules/a"},
+		{name: "the root itself", workspaces: `["**"]`, dir: "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeWorkspace(t, root, map[string]string{
+				"package.json": `{"name":"root","workspaces":` + tt.workspaces + `}`,
+			})
+
+			if got := DeclaresPackage(root, filepath.Join(root, tt.dir)); got != tt.want {
+				t.Errorf("DeclaresPackage(%s) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceSelect(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspace(t, root, map[string]string{
+		"package.json":               `{"name":"root","workspaces":["packages/*","apps/*"]}`,
+		"packages/core/package.json": `{"name":"@app/core"}`,
+		"packages/ui/package.json":   `{"name":"@app/ui","dependencies":{"@app/core":"*"}}`,
+		"apps/web/package.json":      `{"name":"web","dependencies":{"@app/ui":"*","react":"^18"}}`,
+		"apps/docs/package.json":     `{"name":"docs","devDependencies":{"@app/core":"*"}}`,
+	})
+
+	workspace, err := LoadWorkspace(root)
+	if err != nil {
+		t.Fatalf("LoadWorkspace() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		cwd     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "package name", filters: []string{"web"}, want: []string{"web"}},
+		{name: "name glob", filters: []string{"@app/*"}, want: []string{"@app/core", "@app/ui"}},
+		{name: "directory glob", filters: []string{"./apps/*"}, want: []string{"docs", "web"}},
+		{name: "directory relative to cwd", filters: []string{"{./ui}"}, cwd: "packages", want: []string{"@app/ui"}},
+		{name: "dependencies", filters: []string{"web..."}, want: []string{"web", "@app/core", "@app/ui"}},
+		{name: "dependencies only", filters: []string{"web^..."}, want: []string{"@app/core", "@app/ui"}},
+		{name: "dependents", filters: []string{"...@app/core"}, want: []string{"docs", "web", "@app/core", "@app/ui"}},
+		{name: "dependents only", filters: []string{"...^@app/ui"}, want: []string{"web"}},
+		{name: "no match", filters: []string{"api"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, err := workspace.Select(tt.filters, filepath.Join(root, tt.cwd))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Select(%v) expected error", tt.filters)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select(%v) error = %v", tt.filters, err)
+			}
+
+			var got []string
+			for _, pkg := range packages {
+				got = append(got, pkg.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Select(%v) = %v, want %v", tt.filters, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Select(%v) = %v, want %v", tt.filters, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLevels(t *testing.T) {
+	packages := []WorkspacePackage{
+		{Name: "web", Dependencies: []string{"@app/ui", "react"}},
+		{Name: "@app/ui", Dependencies: []string{"@app/core"}},
+		{Name: "docs", Dependencies: []string{"@app/core"}},
+		{Name: "@app/core"},
+	}
+
+	levels, err := Levels(packages)
+	if err != nil {
+		t.Fatalf("Levels() error = %v", err)
+	}
+
+	var got [][]string
+	for _, level := range levels {
+		var names []string
+		for _, pkg := range level {
+			names = append(names, pkg.Name)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{{"@app/core"}, {"@app/ui", "docs"}, {"web"}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Levels() = %v, want %v", got, want)
+	}
+
+	cyclic := []WorkspacePackage{
+		{Name: "a", Dependencies: []string{"b"}},
+		{Name: "b", Dependencies: []string{"a"}},
+	}
+	if _, err := Levels(cyclic); err == nil {
+		t.Error("Levels() expected an error for a dependency cycle")
+	}
+}
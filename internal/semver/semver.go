@@ -0,0 +1,272 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// Parse parses a version such as "1.2.3", "v1.2.3" or "1.2.3-rc.1+build"
+func Parse(value string) (Version, error) {
+	v, wildcards, err := parsePartial(value)
+	if err != nil {
+		return Version{}, err
+	}
+	if wildcards > 0 {
+		return Version{}, fmt.Errorf("incomplete version %q", value)
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether a is lower, equal or higher than b
+func Compare(a, b Version) int {
+	for _, pair := range [][2]int{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case a.Prerelease == b.Prerelease:
+		return 0
+	case a.Prerelease == "":
+		return 1
+	case b.Prerelease == "":
+		return -1
+	case a.Prerelease < b.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Satisfies reports whether version matches the npm-style range, e.g.
+// "^9.0.0", ">=8 <10", "1.2.x", "1.2.3 - 2.0.0" or "^7 || ^8"
+func Satisfies(version, constraint string) (bool, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return false, err
+	}
+
+	for _, set := range strings.Split(constraint, "||") {
+		comparators, err := parseSet(set)
+		if err != nil {
+			return false, err
+		}
+
+		matched := true
+		for _, c := range comparators {
+			if !c.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// MinMajor returns the lowest major version a range can match, e.g. 4 for "^4.1.0"
+func MinMajor(constraint string) (int, bool) {
+	first, _, _ := strings.Cut(constraint, "||")
+	comparators, err := parseSet(first)
+	if err != nil {
+		return 0, false
+	}
+
+	for _, c := range comparators {
+		if c.op == ">=" || c.op == ">" || c.op == "=" {
+			return c.version.Major, true
+		}
+	}
+	return 0, false
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+func parseSet(set string) ([]comparator, error) {
+	fields := strings.Fields(set)
+
+	// Hyphen ranges: "1.2.3 - 2.3.4"
+	if len(fields) == 3 && fields[1] == "-" {
+		low, err := expand(">=", fields[0])
+		if err != nil {
+			return nil, err
+		}
+		high, err := expand("<=", fields[2])
+		if err != nil {
+			return nil, err
+		}
+		return append(low, high...), nil
+	}
+
+	var comparators []comparator
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		// Allow a space between an operator and its version: ">= 1.2.3"
+		if isOperator(field) && i+1 < len(fields) {
+			field += fields[i+1]
+			i++
+		}
+
+		op, value := splitOperator(field)
+		expanded, err := expand(op, value)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, expanded...)
+	}
+
+	return comparators, nil
+}
+
+func isOperator(field string) bool {
+	switch field {
+	case ">", ">=", "<", "<=", "=", "^", "~":
+		return true
+	}
+	return false
+}
+
+func splitOperator(field string) (string, string) {
+	for _, op := range []string{">=", "<=", "~>", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(field, op) {
+			if op == "~>" {
+				op = "~"
+			}
+			return op, strings.TrimPrefix(strings.TrimPrefix(field, "~>"), op)
+		}
+	}
+	return "", field
+}
+
+// expand turns a single operator and (possibly partial) version into comparators
+func expand(op, value string) ([]comparator, error) {
+	v, wildcards, err := parsePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if wildcards == 3 {
+		// "*", "x" or "" match everything
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	upper := func() Version {
+		switch wildcards {
+		case 2:
+			return Version{Major: v.Major + 1, Prerelease: "0"}
+		default:
+			return Version{Major: v.Major, Minor: v.Minor + 1, Prerelease: "0"}
+		}
+	}
+
+	switch op {
+	case "^":
+		high := Version{Major: v.Major + 1, Prerelease: "0"}
+		if v.Major == 0 && wildcards < 2 {
+			high = Version{Minor: v.Minor + 1, Prerelease: "0"}
+			if v.Minor == 0 && wildcards == 0 {
+				high = Version{Patch: v.Patch + 1, Prerelease: "0"}
+			}
+		}
+		return []comparator{{">=", v}, {"<", high}}, nil
+	case "~":
+		high := Version{Major: v.Major, Minor: v.Minor + 1, Prerelease: "0"}
+		if wildcards == 2 {
+			high = Version{Major: v.Major + 1, Prerelease: "0"}
+		}
+		return []comparator{{">=", v}, {"<", high}}, nil
+	case "", "=":
+		if wildcards > 0 {
+			return []comparator{{">=", v}, {"<", upper()}}, nil
+		}
+		return []comparator{{"=", v}}, nil
+	case ">":
+		if wildcards > 0 {
+			return []comparator{{">=", upper()}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case "<=":
+		if wildcards > 0 {
+			return []comparator{{"<", upper()}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	default:
+		return []comparator{{op, v}}, nil
+	}
+}
+
+// parsePartial parses versions that may omit or wildcard trailing parts,
+// returning the number of missing parts
+func parsePartial(value string) (Version, int, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "v")
+
+	// Build metadata never affects precedence
+	value, _, _ = strings.Cut(value, "+")
+	value, prerelease, _ := strings.Cut(value, "-")
+
+	if value == "" {
+		return Version{}, 3, nil
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q", value)
+	}
+
+	numbers := [3]int{}
+	wildcards := 3
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", value)
+		}
+		numbers[i] = n
+		wildcards = 2 - i
+	}
+
+	v := Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}
+	if wildcards == 0 {
+		v.Prerelease = prerelease
+	}
+	return v, wildcards, nil
+}
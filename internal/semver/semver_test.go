@@ -0,0 +1,79 @@
+package semver
+
+import "testing"
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"9.1.0", "^9.0.0", true},
+		{"10.0.0", "^9.0.0", false},
+		{"8.15.0", "^9.0.0", false},
+		{"0.2.5", "^0.2.0", true},
+		{"0.3.0", "^0.2.0", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"9.1.0", ">=8 <10", true},
+		{"10.0.0", ">=8 <10", false},
+		{"9.1.0", ">= 9.1.0", true},
+		{"1.2.7", "1.2.x", true},
+		{"1.3.0", "1.2.x", false},
+		{"4.1.0", "4", true},
+		{"2.5.0", "1.2.3 - 2.6", true},
+		{"2.7.0", "1.2.3 - 2.6", false},
+		{"8.0.0", "^7 || ^8", true},
+		{"9.0.0", "^7 || ^8", false},
+		{"1.22.19", "*", true},
+		{"1.22.19", "", true},
+		{"1.22.19", "1.22.19", true},
+		{"1.22.18", "=1.22.19", false},
+		{"9.0.0-rc.1", ">=9.0.0", false},
+		{"v20.11.0", ">=20", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			got, err := Satisfies(tt.version, tt.constraint)
+			if err != nil {
+				t.Fatalf("Satisfies(%q, %q) error = %v", tt.version, tt.constraint, err)
+			}
+			if got != tt.want {
+				t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSatisfiesInvalid(t *testing.T) {
+	if _, err := Satisfies("not-a-version", "^1.0.0"); err == nil {
+		t.Error("expected error for invalid version")
+	}
+	if _, err := Satisfies("1.0.0", "^one"); err == nil {
+		t.Error("expected error for invalid range")
+	}
+}
+
+func TestMinMajor(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       int
+		wantOK     bool
+	}{
+		{"^4.1.0", 4, true},
+		{"3.x", 3, true},
+		{">=2 <5", 2, true},
+		{"1.22.19", 1, true},
+		{"<2", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, ok := MinMajor(tt.constraint)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MinMajor(%q) = (%d, %v), want (%d, %v)", tt.constraint, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var safeWord = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// Quote quotes a word for POSIX shells unless it needs no quoting
+func Quote(word string) string {
+	if safeWord.MatchString(word) {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// Join spells words as a command line that can be pasted into a shell
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = Quote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// cmdSpecial are the characters cmd.exe interprets outside of quotes
+var cmdSpecial = regexp.MustCompile(`[ !%^&()<>|"]`)
+
+// QuoteCmd quotes a word for a command line run by cmd.exe. The word is
+// quoted for the program's argument parser, then cmd.exe's special
+// characters are escaped with ^, the way npm quotes script arguments.
+func QuoteCmd(word string) string {
+	if word == "" {
+		return `""`
+	}
+
+	quoted := word
+	if strings.ContainsAny(word, " \t\n\v\"") {
+		var b strings.Builder
+		b.WriteByte('"')
+		backslashes := 0
+		for i := 0; i < len(word); i++ {
+			switch word[i] {
+			case '\\':
+				backslashes++
+				continue
+			case '"':
+				// Backslashes before a quote, and the quote itself, are escaped
+				b.WriteString(strings.Repeat(`\`, backslashes*2+1))
+			default:
+				b.WriteString(strings.Repeat(`\`, backslashes))
+			}
+			backslashes = 0
+			b.WriteByte(word[i])
+		}
+		// Backslashes before the closing quote are escaped too
+		b.WriteString(strings.Repeat(`\`, backslashes*2))
+		b.WriteByte('"')
+		quoted = b.String()
+	}
+	return cmdSpecial.ReplaceAllString(quoted, "^$0")
+}
+
+// JoinCmd spells words as a command line for cmd.exe
+func JoinCmd(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = QuoteCmd(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Split breaks a command line into words the way a POSIX shell would,
+// honoring single quotes, double quotes and backslash escapes. It does not
+// expand variables or globs.
+func Split(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in %q", line)
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated double quote in %q", line)
+			}
+			inWord = true
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
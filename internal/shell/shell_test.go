@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "yarn add -D react", want: []string{"yarn", "add", "-D", "react"}},
+		{line: "  npm   ci  ", want: []string{"npm", "ci"}},
+		{line: `npm run dev -- --host "0.0.0.0"`, want: []string{"npm", "run", "dev", "--", "--host", "0.0.0.0"}},
+		{line: `echo 'it'\''s' "a \"b\"" c\ d`, want: []string{"echo", "it's", `a "b"`, "c d"}},
+		{line: `npm test ''`, want: []string{"npm", "test", ""}},
+		{line: `echo 'open`, wantErr: true},
+		{line: `echo "open`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := Split(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Split(%q) = %q, expected error", tt.line, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Split(%q) error = %v", tt.line, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	words := []string{"pnpm", "--filter", "@app/*", "add", "it's", "a b", ""}
+	want := `pnpm --filter '@app/*' add 'it'\''s' 'a b' ''`
+	if got := Join(words); got != want {
+		t.Errorf("Join() = %s, want %s", got, want)
+	}
+
+	split, err := Split(want)
+	if err != nil || !slices.Equal(split, words) {
+		t.Errorf("Split(Join()) = %q, %v, want %q", split, err, words)
+	}
+}
+
+func TestQuoteCmd(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"build", "build"},
+		{"", `""`},
+		{"a b", `^"a^ b^"`},
+		{`say "hi"`, `^"say^ \^"hi\^"^"`},
+		{`C:\my dir\`, `^"C:\my^ dir\\^"`},
+		{`C:\bin`, `C:\bin`},
+		{"100%", "100^%"},
+		{"a&b|c", "a^&b^|c"},
+	}
+
+	for _, tt := range tests {
+		if got := QuoteCmd(tt.word); got != tt.want {
+			t.Errorf("QuoteCmd(%q) = %s, want %s", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package translator
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"pm/internal/detector"
+	"pm/internal/shell"
+)
+
+// WithDirectScripts runs package.json scripts with the shell instead of
+// through the package manager, saving its startup time
+func WithDirectScripts(direct bool) Option {
+	return func(t *Translator) {
+		t.direct = direct
+	}
+}
+
+// WithPackageDir sets the directory of the package the manifest belongs to,
+// where scripts run
+func WithPackageDir(dir string) Option {
+	return func(t *Translator) {
+		t.packageDir = dir
+	}
+}
+
+// platform is the operating system scripts run on, a variable for tests
+var platform = runtime.GOOS
+
+// runsDirect reports whether scripts can run without the package manager
+func (t *Translator) runsDirect() bool {
+	// yarn berry sets up Plug'n'Play for its scripts, deno runs tasks
+	return t.direct && t.packageManager != detector.YarnBerry && t.packageManager != detector.Deno
+}
+
+// translateDirect runs a script with the platform shell, in the environment
+// the package manager would give it. Extra arguments are appended quoted for
+// that shell.
+func (t *Translator) translateDirect(name, script string, args []string) *Command {
+	binary, flags, join := "sh", []string{"-c"}, shell.Join
+	if platform == "windows" {
+		binary, flags, join = "cmd", []string{"/d", "/s", "/c"}, shell.JoinCmd
+	}
+
+	line := script
+	if len(args) > 0 {
+		line += " " + join(args)
+	}
+
+	cmd := &Command{
+		Binary: binary,
+		Dir:    t.packageDir,
+		Args:   append(flags, line),
+		Env:    t.scriptEnv(name, script),
+	}
+	cmd.rule("%s runs with %s directly, without %s", name, binary, t.packageManager)
+	return cmd
+}
+
+// scriptEnv is the environment package managers set for a script: every
+// node_modules/.bin up to the workspace root on PATH, and the npm_* variables
+func (t *Translator) scriptEnv(name, script string) []string {
+	pathKey, separator := "PATH", ":"
+	if platform == "windows" {
+		pathKey, separator = windowsPathKey(), ";"
+	}
+	path := os.Getenv(pathKey)
+	if len(t.binDirs) > 0 {
+		path = strings.Join(append(slices.Clone(t.binDirs), path), separator)
+	}
+
+	version := t.version
+	if version == "" {
+		version = "?"
+	}
+
+	env := []string{
+		pathKey + "=" + path,
+		"npm_lifecycle_event=" + name,
+		"npm_lifecycle_script=" + script,
+		"npm_package_name=" + t.manifest.Name,
+		"npm_package_version=" + t.manifest.Version,
+		fmt.Sprintf("npm_config_user_agent=%s/%s pm", t.packageManager.Binary(), version),
+	}
+	// INIT_CWD is where the command was started, before moving to the package
+	if cwd, err := os.Getwd(); err == nil {
+		env = append(env, "INIT_CWD="+cwd)
+	}
+	return env
+}
+
+// windowsPathKey is the name the environment spells PATH with, usually Path,
+// so scripts see the variable under the name they already know
+func windowsPathKey() string {
+	for _, variable := range os.Environ() {
+		if key, _, _ := strings.Cut(variable, "="); strings.EqualFold(key, "PATH") {
+			return key
+		}
+	}
+	return "Path"
+}
@@ -0,0 +1,128 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"pm/internal/detector"
+)
+
+// dlxArgs is a parsed `pm dlx [--package <spec>]... <command> [args...]`
+type dlxArgs struct {
+	// packages are the --package specs providing the command
+	packages []string
+	// flags are the other options before the command, passed through
+	flags []token
+	// command is the binary to run, possibly with a version spec like create-vite@5
+	command string
+	args    []string
+}
+
+// translateDlx runs a package binary without adding it to the project
+func (t *Translator) translateDlx(args []string) (*Command, error) {
+	parsed := t.parseDlxArgs(args)
+	if parsed.command == "" {
+		return nil, fmt.Errorf("dlx requires a package")
+	}
+
+	cmd := t.dlxCommand(parsed)
+	t.passThroughFlags(cmd, parsed.flags)
+	return cmd, nil
+}
+
+// dlxCommand spells the run of a package binary for the package manager
+func (t *Translator) dlxCommand(parsed *dlxArgs) *Command {
+	var flags []string
+	switch t.packageManager {
+	case detector.NPM:
+		if t.atLeast("7") {
+			flags = append(flags, "--yes")
+		}
+		for _, pkg := range parsed.packages {
+			flags = append(flags, "--package="+pkg)
+		}
+		return &Command{Binary: "npx", Flags: flags, Args: parsed.commandLine()}
+	case detector.Yarn:
+		// yarn classic has no dlx; npx ships with node
+		for _, pkg := range parsed.packages {
+			flags = append(flags, "--package="+pkg)
+		}
+		cmd := &Command{Binary: "npx", Flags: flags, Args: parsed.commandLine()}
+		cmd.rule("yarn classic has no dlx, using npx")
+		return cmd
+	case detector.YarnBerry:
+		for _, pkg := range parsed.packages {
+			flags = append(flags, "--package", pkg)
+		}
+		return &Command{Command: []string{"dlx"}, Flags: flags, Args: parsed.commandLine()}
+	case detector.Pnpm:
+		for _, pkg := range parsed.packages {
+			flags = append(flags, "--package="+pkg)
+		}
+		if !t.atLeast("6.13") {
+			cmd := &Command{Binary: "npx", Flags: flags, Args: parsed.commandLine()}
+			cmd.rule("pnpm %s predates pnpm dlx, using npx", t.version)
+			return cmd
+		}
+		return &Command{Command: []string{"dlx"}, Flags: flags, Args: parsed.commandLine()}
+	case detector.Bun:
+		for _, pkg := range parsed.packages {
+			flags = append(flags, "--package", pkg)
+		}
+		return &Command{Command: []string{"x"}, Flags: flags, Args: parsed.commandLine()}
+	case detector.Deno:
+		// Deno addresses a binary of another package as npm:<package>/<binary>
+		spec := "npm:" + parsed.command
+		if len(parsed.packages) > 0 {
+			spec = "npm:" + parsed.packages[0] + "/" + parsed.command
+		}
+		cmd := &Command{Command: []string{"run", "--allow-all"}, Args: append([]string{spec}, parsed.args...)}
+		cmd.rule("%s → %s", parsed.command, spec)
+		cmd.rule("--allow-all grants %s every permission, as the other package managers do", spec)
+		return cmd
+	}
+
+	return &Command{Args: parsed.commandLine()}
+}
+
+// parseDlxArgs splits the options from the command; everything after the
+// command belongs to it
+func (t *Translator) parseDlxArgs(args []string) *dlxArgs {
+	parsed := &dlxArgs{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--package" || arg == "-p":
+			if i+1 < len(args) {
+				parsed.packages = append(parsed.packages, args[i+1])
+				i++
+			}
+		case strings.HasPrefix(arg, "--package="):
+			parsed.packages = append(parsed.packages, strings.TrimPrefix(arg, "--package="))
+		case arg == "--":
+			continue
+		case strings.HasPrefix(arg, "--"):
+			name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+			if value == "" && t.isFlagWithValue(name) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				value = args[i+1]
+				i++
+			}
+			parsed.flags = append(parsed.flags, token{flag: name, value: value})
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, short := range arg[1:] {
+				parsed.flags = append(parsed.flags, token{flag: string(short), short: true})
+			}
+		default:
+			parsed.command = arg
+			parsed.args = args[i+1:]
+			return parsed
+		}
+	}
+
+	return parsed
+}
+
+func (d *dlxArgs) commandLine() []string {
+	return append([]string{d.command}, d.args...)
+}
@@ -0,0 +1,87 @@
+package translator
+
+import "pm/internal/detector"
+
+// HookPolicy decides what happens to the pre<script> and post<script>
+// lifecycle scripts around a script
+type HookPolicy int
+
+const (
+	// HooksDefault leaves them to the package manager
+	HooksDefault HookPolicy = iota
+	// HooksRun runs them, emulating them where the package manager skips them
+	HooksRun
+	// HooksSkip skips them, as npm does with --ignore-scripts
+	HooksSkip
+)
+
+// WithHooks sets what happens to the pre and post scripts around scripts
+func WithHooks(policy HookPolicy) Option {
+	return func(t *Translator) {
+		t.hooks = policy
+	}
+}
+
+// runsHooks reports whether the package manager runs pre and post scripts on its own
+func (t *Translator) runsHooks() bool {
+	switch t.packageManager {
+	case detector.NPM, detector.Yarn, detector.Bun:
+		return true
+	case detector.Pnpm:
+		// pnpm 7 turned enable-pre-post-scripts off
+		return !t.atLeast("7")
+	}
+	return false
+}
+
+// directHooks runs the pre and post scripts around a script pm runs itself,
+// when the package manager would have run them or the policy asks for them
+func (t *Translator) directHooks(run *Command, script string) {
+	if t.hooks == HooksSkip || (t.hooks == HooksDefault && !t.runsHooks()) {
+		return
+	}
+
+	pre, post := t.manifest.Hooks(script)
+	if pre != nil {
+		run.Before = append(run.Before, t.translateDirect(pre.Name, pre.Command, nil))
+		run.rule("%s runs first", pre.Name)
+	}
+	if post != nil {
+		run.After = append(run.After, t.translateDirect(post.Name, post.Command, nil))
+		run.rule("%s runs after", post.Name)
+	}
+}
+
+// applyHooks makes run, which runs script, honor the hook policy
+func (t *Translator) applyHooks(run *Command, script string) {
+	pre, post := t.manifest.Hooks(script)
+	if pre == nil && post == nil {
+		return
+	}
+
+	switch t.hooks {
+	case HooksRun:
+		if t.runsHooks() {
+			return
+		}
+		if pre != nil {
+			run.Before = append(run.Before, &Command{Command: Commands.Run[t.packageManager], Args: []string{pre.Name}})
+			run.rule("%s runs first: %s skips pre scripts", pre.Name, t.packageManager)
+		}
+		if post != nil {
+			run.After = append(run.After, &Command{Command: Commands.Run[t.packageManager], Args: []string{post.Name}})
+			run.rule("%s runs after: %s skips post scripts", post.Name, t.packageManager)
+		}
+
+	case HooksSkip:
+		if !t.runsHooks() {
+			return
+		}
+		if t.packageManager == detector.NPM {
+			run.Flags = append(run.Flags, "--ignore-scripts")
+			run.rule("--ignore-scripts skips the pre and post scripts of %s", script)
+			return
+		}
+		run.warn("pre and post scripts of %s still run: %s cannot skip them", script, t.packageManager)
+	}
+}
@@ -0,0 +1,629 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"pm/internal/detector"
+	"pm/internal/shell"
+)
+
+// Neutral is a command in pm's own vocabulary, e.g. parsed from the native
+// command line of one package manager to be rendered for another
+type Neutral struct {
+	// Args is the pm command line, e.g. ["add", "--save-dev", "react"].
+	// Scripts are run with ["run", script, args...].
+	Args []string
+	// Workspaces names the workspace packages the command is scoped to
+	Workspaces []string
+	// Recursive runs the script in Args[0] in every workspace package
+	Recursive bool
+	// Root runs the command in the workspace root, as pnpm -w does
+	Root bool
+
+	// from is the package manager a native command line was written for
+	from detector.PackageManager
+}
+
+// neutralCommands are the pm commands that mean the same to every package manager
+var neutralCommands = []string{"install", "add", "rm", "ci", "update", "dlx", "run"}
+
+// pmAliases are the alternative spellings of pm's own commands
+var pmAliases = map[string]string{
+	"i": "install", "remove": "rm", "uninstall": "rm", "un": "rm",
+	"upgrade": "update", "x": "dlx",
+}
+
+// nativeCommands maps native subcommands onto pm's commands
+var nativeCommands = map[detector.PackageManager]map[string]string{
+	detector.NPM: {
+		"install": "install", "i": "install", "in": "install", "add": "install",
+		"uninstall": "rm", "remove": "rm", "rm": "rm", "r": "rm", "un": "rm",
+		"ci": "ci", "clean-install": "ci", "ic": "ci", "install-clean": "ci",
+		"update": "update", "up": "update", "upgrade": "update",
+		"run": "run", "run-script": "run",
+		"exec": "dlx", "x": "dlx",
+	},
+	detector.Yarn: {
+		"install": "install", "add": "add", "remove": "rm",
+		"upgrade": "update", "upgrade-interactive": "update",
+		"run": "run",
+	},
+	detector.YarnBerry: {
+		"install": "install", "add": "add", "remove": "rm",
+		"up": "update", "upgrade-interactive": "update",
+		"dlx": "dlx", "run": "run",
+	},
+	detector.Pnpm: {
+		"install": "install", "i": "install", "add": "add",
+		"remove": "rm", "rm": "rm", "uninstall": "rm", "un": "rm",
+		"update": "update", "up": "update", "upgrade": "update",
+		"dlx": "dlx", "run": "run", "run-script": "run",
+	},
+	detector.Bun: {
+		"install": "install", "i": "install", "add": "add", "a": "add",
+		"remove": "rm", "rm": "rm", "update": "update",
+		"x": "dlx", "run": "run",
+	},
+	detector.Deno: {
+		"install": "install", "i": "install", "add": "add", "remove": "rm",
+		"outdated": "update", "run": "dlx", "task": "run",
+	},
+}
+
+// nativeScripts maps the built-ins that run a script onto the script they run
+var nativeScripts = map[detector.PackageManager]map[string]string{
+	detector.NPM: {
+		"test": "test", "t": "test", "tst": "test", "start": "start", "stop": "stop",
+	},
+	detector.Yarn: {
+		"test": "test",
+	},
+	detector.Pnpm: {
+		"test": "test", "t": "test", "tst": "test", "start": "start",
+	},
+}
+
+// nativeFlags maps native flags onto the npm-style spelling pm accepts.
+// --workspace-root marks a command for the workspace root.
+var nativeFlags = map[detector.PackageManager]map[string]string{
+	detector.NPM: {
+		"-P": "--save-prod",
+	},
+	detector.Yarn: {
+		"--dev": "--save-dev", "-D": "--save-dev",
+		"--peer": "--save-peer", "-P": "--save-peer",
+		"--optional": "--save-optional", "-O": "--save-optional",
+		"--exact": "--save-exact", "-E": "--save-exact",
+		"--ignore-workspace-root-check": "--workspace-root", "-W": "--workspace-root",
+	},
+	detector.YarnBerry: {
+		"--dev": "--save-dev", "-D": "--save-dev",
+		"--peer": "--save-peer", "-P": "--save-peer",
+		"--optional": "--save-optional", "-O": "--save-optional",
+		"--exact": "--save-exact", "-E": "--save-exact",
+		"--immutable": "--frozen-lockfile",
+	},
+	detector.Pnpm: {
+		"--prod": "--production", "-P": "--production", "-D": "--save-dev",
+		"-w": "--workspace-root",
+	},
+	detector.Bun: {
+		"--dev": "--save-dev", "-d": "--save-dev", "-D": "--save-dev",
+		"--peer": "--save-peer", "--optional": "--save-optional",
+		"--exact": "--save-exact", "-E": "--save-exact",
+		"--production": "--production", "-p": "--production",
+	},
+	detector.Deno: {
+		"--dev": "--save-dev", "-D": "--save-dev",
+		"--frozen": "--frozen-lockfile",
+	},
+}
+
+var shortFlagGroup = regexp.MustCompile(`^-[A-Za-z]{2,}$`)
+
+// ParsePM builds the neutral form of a pm command line whose global options
+// were already removed. Commands that are neither pm commands nor built-ins of
+// a package manager run scripts, as they would in pm.
+func ParsePM(args []string) *Neutral {
+	if len(args) == 0 {
+		return &Neutral{Args: []string{"install"}}
+	}
+
+	command := args[0]
+	if alias, ok := pmAliases[command]; ok {
+		command = alias
+	}
+	if slices.Contains(neutralCommands, command) {
+		return &Neutral{Args: append([]string{command}, args[1:]...)}
+	}
+
+	for _, pm := range detector.Supported {
+		if IsBuiltIn(pm, command) {
+			return &Neutral{Args: args}
+		}
+	}
+	return &Neutral{Args: append([]string{"run"}, args...)}
+}
+
+// nativeBinary identifies the package manager behind an executable. from
+// tells yarn classic and berry apart and may be empty for yarn classic.
+func nativeBinary(binary string, from detector.PackageManager) (pm detector.PackageManager, dlx bool, ok bool) {
+	switch binary {
+	case "npm":
+		pm = detector.NPM
+	case "npx":
+		pm, dlx = detector.NPM, true
+	case "yarn":
+		pm = detector.Yarn
+		if from == detector.YarnBerry {
+			pm = detector.YarnBerry
+		}
+	case "pnpm":
+		pm = detector.Pnpm
+	case "pnpx":
+		pm, dlx = detector.Pnpm, true
+	case "bun":
+		pm = detector.Bun
+	case "bunx":
+		pm, dlx = detector.Bun, true
+	case "deno":
+		pm = detector.Deno
+	default:
+		return "", false, false
+	}
+	return pm, dlx, true
+}
+
+// IsNativeCommand reports whether a command line starts with the executable
+// of a package manager, e.g. npm or npx
+func IsNativeCommand(words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	_, _, ok := nativeBinary(words[0], "")
+	return ok
+}
+
+// ParseNative parses a native command line, executable included, such as
+// "yarn add -D react". from names the package manager the command line is
+// written for; when empty it is taken from the executable.
+func ParseNative(words []string, from detector.PackageManager) (*Neutral, error) {
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no command to translate")
+	}
+
+	pm, dlx, ok := nativeBinary(words[0], from)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a package manager command", words[0])
+	}
+	if from != "" && pm != from {
+		return nil, fmt.Errorf("%s is a %s command, not %s", words[0], pm, from)
+	}
+
+	if dlx {
+		return parseNativeDlx(pm, words[1:])
+	}
+	return parseNative(pm, words[1:])
+}
+
+func parseNative(pm detector.PackageManager, args []string) (*Neutral, error) {
+	n := &Neutral{from: pm}
+
+	// Options selecting workspace packages come before the subcommand
+options:
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		arg := args[0]
+		switch {
+		case (arg == "--filter" || arg == "-F") && (pm == detector.Pnpm || pm == detector.Bun),
+			(arg == "--workspace" || arg == "-w") && pm == detector.NPM:
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s requires a workspace package", arg)
+			}
+			n.Workspaces = append(n.Workspaces, args[1])
+			args = args[2:]
+			continue
+		case strings.HasPrefix(arg, "--filter=") && (pm == detector.Pnpm || pm == detector.Bun):
+			n.Workspaces = append(n.Workspaces, strings.TrimPrefix(arg, "--filter="))
+		case strings.HasPrefix(arg, "--workspace=") && pm == detector.NPM:
+			n.Workspaces = append(n.Workspaces, strings.TrimPrefix(arg, "--workspace="))
+		case (arg == "-r" || arg == "--recursive") && pm == detector.Pnpm:
+			n.Recursive = true
+		case (arg == "-w" || arg == "--workspace-root") && pm == detector.Pnpm:
+			n.Root = true
+		case pm == detector.Yarn || pm == detector.YarnBerry:
+			// A bare yarn installs, e.g. yarn --frozen-lockfile
+			args = append([]string{"install"}, args...)
+			break options
+		default:
+			return nil, fmt.Errorf("cannot translate %s option %s", pm, arg)
+		}
+		args = args[1:]
+	}
+
+	if (pm == detector.Yarn || pm == detector.YarnBerry) && len(args) >= 2 && args[0] == "workspace" {
+		n.Workspaces = append(n.Workspaces, args[1])
+		args = args[2:]
+	}
+
+	if len(args) == 0 {
+		// A bare yarn installs
+		if pm != detector.Yarn && pm != detector.YarnBerry {
+			return nil, fmt.Errorf("no %s command to translate", pm)
+		}
+		args = []string{"install"}
+	}
+
+	command, rest := args[0], args[1:]
+	neutral, ok := nativeCommands[pm][command]
+	if script, isScript := nativeScripts[pm][command]; isScript {
+		neutral, rest, ok = "run", append([]string{script}, rest...), true
+	}
+	if !ok {
+		if IsBuiltIn(pm, command) {
+			if n.Recursive {
+				return nil, fmt.Errorf("cannot translate %s -r %s", pm, command)
+			}
+			n.Args = args
+			return n, nil
+		}
+		// Anything else runs a script
+		neutral, rest = "run", args
+	}
+
+	if n.Recursive {
+		if neutral != "run" || len(rest) == 0 {
+			return nil, fmt.Errorf("cannot translate %s -r %s", pm, command)
+		}
+		n.Args = rest
+		return n, nil
+	}
+
+	switch neutral {
+	case "install", "add", "rm", "update":
+		flags := mapNativeFlags(pm, rest)
+		if i := slices.Index(flags, "--workspace-root"); i >= 0 && !slices.Contains(flags[:i], "--") {
+			n.Root = true
+			flags = slices.Delete(flags, i, i+1)
+		}
+		switch {
+		case neutral == "install":
+			packages := (&Translator{}).parseArgs(flags).packages()
+			if len(packages) > 0 {
+				neutral = "add"
+			} else if i := slices.Index(flags, "--frozen-lockfile"); i >= 0 {
+				neutral = "ci"
+				flags = slices.Delete(flags, i, i+1)
+			}
+		case command == "up" && pm == detector.YarnBerry:
+			// yarn up always moves to the latest version
+			flags = append(flags, "--latest")
+		case command == "upgrade-interactive":
+			flags = append(flags, "--interactive")
+		case command == "outdated":
+			i := slices.Index(flags, "--update")
+			if i < 0 {
+				n.Args = args
+				return n, nil
+			}
+			flags = slices.Delete(flags, i, i+1)
+		}
+		n.Args = append([]string{neutral}, flags...)
+	case "dlx":
+		return parseNativeDlx(pm, rest)
+	default:
+		n.Args = append([]string{neutral}, rest...)
+	}
+	return n, nil
+}
+
+// mapNativeFlags respells native flags the way pm accepts them, spelling out
+// grouped short flags such as -DE when the package manager knows every letter
+func mapNativeFlags(pm detector.PackageManager, args []string) []string {
+	mapped := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			mapped = append(mapped, args[i:]...)
+			break
+		}
+
+		if shortFlagGroup.MatchString(arg) {
+			var expanded []string
+			for _, short := range arg[1:] {
+				flag, ok := nativeFlags[pm]["-"+string(short)]
+				if !ok {
+					expanded = nil
+					break
+				}
+				expanded = append(expanded, flag)
+			}
+			if expanded != nil {
+				mapped = append(mapped, expanded...)
+				continue
+			}
+		}
+
+		if flag, ok := nativeFlags[pm][arg]; ok {
+			mapped = append(mapped, flag)
+		} else {
+			mapped = append(mapped, arg)
+		}
+	}
+	return mapped
+}
+
+// parseNativeDlx parses the arguments of npx, npm exec, yarn dlx, pnpm dlx,
+// bun x and deno run npm:<package>
+func parseNativeDlx(pm detector.PackageManager, args []string) (*Neutral, error) {
+	neutral := []string{"dlx"}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--package" || arg == "-p":
+			if i+1 < len(args) {
+				neutral = append(neutral, "--package", args[i+1])
+				i++
+			}
+		case strings.HasPrefix(arg, "--package="):
+			neutral = append(neutral, "--package", strings.TrimPrefix(arg, "--package="))
+		case arg == "--":
+			continue
+		case strings.HasPrefix(arg, "-"):
+			// Prompts and deno permissions have no counterpart
+			continue
+		default:
+			command := arg
+			if pm == detector.Deno {
+				pkg, binary, ok := splitDenoNpmSpec(arg)
+				if !ok {
+					return nil, fmt.Errorf("deno run %s runs a module, not a package binary", arg)
+				}
+				command = pkg
+				if binary != "" {
+					neutral = append(neutral, "--package", pkg)
+					command = binary
+				}
+			}
+			neutral = append(neutral, command)
+			neutral = append(neutral, args[i+1:]...)
+			return &Neutral{Args: neutral}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no package binary to translate")
+}
+
+// splitDenoNpmSpec splits "npm:<package>[/<binary>]"
+func splitDenoNpmSpec(spec string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(spec, "npm:")
+	if !ok || rest == "" {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if strings.HasPrefix(rest, "@") {
+		if len(parts) < 2 {
+			return "", "", false
+		}
+		if len(parts) == 3 {
+			return parts[0] + "/" + parts[1], parts[2], true
+		}
+		return rest, "", true
+	}
+	if len(parts) > 1 {
+		return parts[0], strings.Join(parts[1:], "/"), true
+	}
+	return rest, "", true
+}
+
+// translatable reports whether the command means the same to every package
+// manager, rather than being a built-in of the one it was written for
+func (n *Neutral) translatable() bool {
+	return n.Recursive || (len(n.Args) > 0 && slices.Contains(neutralCommands, n.Args[0]))
+}
+
+// Render translates the command for a package manager. Commands that may
+// not translate faithfully carry warnings; with WithStrict they fail instead.
+func (n *Neutral) Render(pm detector.PackageManager, options ...Option) (*Command, error) {
+	if len(n.Args) == 0 {
+		return nil, fmt.Errorf("no command to translate")
+	}
+	tr := New(pm, options...)
+
+	var cmd *Command
+	workspaces := n.Workspaces
+	switch {
+	case n.Recursive:
+		var ok bool
+		if cmd, ok = tr.RecursiveRun(n.Args[0], n.Args[1:], false, false); !ok {
+			return nil, fmt.Errorf("%s has no recursive run in dependency order; use pm -r %s", pm, n.Args[0])
+		}
+		if len(n.Workspaces) > 0 && pm == detector.YarnBerry {
+			// yarn workspaces foreach narrows itself down
+			for _, name := range n.Workspaces {
+				cmd.Prefix = append(cmd.Prefix, "--include", name)
+			}
+			workspaces = nil
+		}
+	case n.Args[0] == "run":
+		// Scripts stay scripts, even where their name is a built-in
+		cmd = &Command{Command: Commands.Run[pm], Args: n.Args[1:]}
+	default:
+		if !n.translatable() && !IsBuiltIn(pm, n.Args[0]) {
+			return nil, fmt.Errorf("%s has no %s command", pm, n.Args[0])
+		}
+		var err error
+		if cmd, err = tr.Translate(pm, n.Args); err != nil {
+			return nil, err
+		}
+		if !n.translatable() && n.from != "" && n.from != pm {
+			cmd.warn("%s %s is copied as it is: %s %s may do something else", n.from, n.Args[0], pm, n.Args[0])
+		}
+	}
+
+	if n.Root {
+		n.renderRoot(cmd, pm)
+	}
+	if len(workspaces) > 0 {
+		var err error
+		if cmd, err = tr.ForWorkspaces(cmd, "", workspaces); err != nil {
+			return nil, err
+		}
+	}
+
+	if tr.strict && len(cmd.Warnings) > 0 {
+		return nil, fmt.Errorf("strict mode: %s", strings.Join(cmd.Warnings, "; "))
+	}
+	return cmd, nil
+}
+
+// renderRoot points cmd at the workspace root. Installing always covers the
+// whole workspace; other commands need the package manager's root option.
+func (n *Neutral) renderRoot(cmd *Command, pm detector.PackageManager) {
+	if n.Args[0] == "install" || n.Args[0] == "ci" {
+		return
+	}
+
+	switch {
+	case pm == detector.Pnpm:
+		cmd.Prefix = append(cmd.Prefix, "-w")
+	case pm == detector.Yarn && n.Args[0] == "add":
+		// yarn classic only guards adding to the root
+		cmd.Flags = append(cmd.Flags, "-W")
+	default:
+		cmd.warn("%s has no option for the workspace root: run it from the workspace root", pm)
+	}
+}
+
+// Words spells the command as a command line of the package manager,
+// executable included
+func (c *Command) Words(pm detector.PackageManager) []string {
+	binary := c.Binary
+	if binary == "" {
+		binary = pm.Binary()
+	}
+	words := []string{binary}
+	words = append(words, c.Prefix...)
+	words = append(words, c.Command...)
+	words = append(words, c.Flags...)
+	words = append(words, c.Args...)
+	return words
+}
+
+// ConvertText rewrites the package manager commands in a shell script or
+// README from one package manager to another. Lines may start with a "$ "
+// prompt, and commands chained with &&, ||, ; or | are converted one by one.
+// Commands that cannot be translated are left as they are. An empty from
+// converts the commands of every package manager.
+func ConvertText(text string, from, to detector.PackageManager) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		lines[i] = convertLine(body, from, to) + line[len(body):]
+	}
+	return strings.Join(lines, "")
+}
+
+var segmentPattern = regexp.MustCompile(`^(\s*(?:\$\s+)?)(.*?)(\s*)$`)
+
+func convertLine(line string, from, to detector.PackageManager) string {
+	// A trailing comment is kept as written, after the converted commands
+	line, comment := splitComment(line)
+
+	var out strings.Builder
+	for _, segment := range splitCommands(line) {
+		match := segmentPattern.FindStringSubmatch(segment.text)
+		converted, ok := convertCommand(match[2], from, to)
+		if ok {
+			out.WriteString(match[1] + converted + match[3])
+		} else {
+			out.WriteString(segment.text)
+		}
+		out.WriteString(segment.separator)
+	}
+	return out.String() + comment
+}
+
+// splitComment splits a line before a # that starts a word outside of quotes
+func splitComment(line string) (string, string) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\':
+			i++
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i], line[i:]
+		}
+	}
+	return line, ""
+}
+
+func convertCommand(command string, from, to detector.PackageManager) (string, bool) {
+	words, err := shell.Split(command)
+	if err != nil || len(words) == 0 {
+		return "", false
+	}
+	if _, _, ok := nativeBinary(words[0], from); !ok {
+		return "", false
+	}
+
+	n, err := ParseNative(words, from)
+	if err != nil || !n.translatable() {
+		return "", false
+	}
+	rendered, err := n.Render(to)
+	if err != nil {
+		return "", false
+	}
+	return shell.Join(rendered.Words(to)), true
+}
+
+type commandSegment struct {
+	text      string
+	separator string
+}
+
+// splitCommands splits a line at &&, ||, ;, | and & outside of quotes
+func splitCommands(line string) []commandSegment {
+	var segments []commandSegment
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\':
+			i++
+		case c == ';' || c == '|' || c == '&':
+			separator := string(c)
+			if i+1 < len(line) && c != ';' && line[i+1] == c {
+				separator += string(c)
+			}
+			segments = append(segments, commandSegment{text: line[start:i], separator: separator})
+			i += len(separator) - 1
+			start = i + 1
+		}
+	}
+
+	return append(segments, commandSegment{text: line[start:]})
+}
@@ -0,0 +1,55 @@
+package translator
+
+import (
+	"pm/internal/project"
+	"pm/internal/semver"
+)
+
+// Option configures a Translator
+type Option func(*Translator)
+
+// WithVersion sets the installed version of the package manager so that
+// translations can pick the spelling that version understands
+func WithVersion(version string) Option {
+	return func(t *Translator) {
+		t.version = version
+	}
+}
+
+// WithManifest gives the translator the package.json of the current package,
+// for translations that expand into the declared dependencies
+func WithManifest(pkg *project.PackageJSON) Option {
+	return func(t *Translator) {
+		t.manifest = pkg
+	}
+}
+
+// WithStrict turns warnings about dropped and unrecognized flags into errors
+func WithStrict(strict bool) Option {
+	return func(t *Translator) {
+		t.strict = strict
+	}
+}
+
+// atLeast reports whether the package manager version is at least min.
+// An unknown version is assumed to be recent.
+func (t *Translator) atLeast(min string) bool {
+	if t.version == "" {
+		return true
+	}
+
+	ok, err := semver.Satisfies(t.version, ">="+min)
+	if err != nil {
+		return true
+	}
+	return ok
+}
+
+// isAvailable reports whether a built-in command exists in the package manager version
+func (t *Translator) isAvailable(command string) bool {
+	since, ok := tables.BuiltInSince[t.packageManager][command]
+	if !ok {
+		return true
+	}
+	return t.atLeast(since)
+}
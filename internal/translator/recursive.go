@@ -0,0 +1,34 @@
+package translator
+
+import "pm/internal/detector"
+
+// RecursiveRun returns the native command running a script in every
+// workspace package in dependency order. It reports false for package managers
+// whose recursive runs are missing or unordered, so pm has to order the runs itself.
+func (t *Translator) RecursiveRun(script string, args []string, parallel, ifPresent bool) (*Command, bool) {
+	var cmd *Command
+	switch t.packageManager {
+	case detector.Pnpm:
+		cmd = &Command{Prefix: []string{"--recursive"}, Command: []string{"run"}}
+		if parallel {
+			// pnpm --parallel ignores the dependency order; --workspace-concurrency keeps it
+			cmd.Flags = append(cmd.Flags, "--workspace-concurrency=Infinity")
+		} else {
+			cmd.Flags = append(cmd.Flags, "--workspace-concurrency=1")
+		}
+		if ifPresent {
+			cmd.Flags = append(cmd.Flags, "--if-present")
+		}
+	case detector.YarnBerry:
+		cmd = &Command{Prefix: []string{"workspaces", "foreach", "--all", "--topological-dev"}, Command: []string{"run"}}
+		if parallel {
+			cmd.Prefix = append(cmd.Prefix, "--parallel")
+		}
+	default:
+		return nil, false
+	}
+
+	cmd.Args = append([]string{script}, args...)
+	cmd.rule("%s orders recursive runs itself", t.packageManager)
+	return cmd, true
+}
@@ -0,0 +1,140 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+
+	"pm/internal/detector"
+	"pm/internal/project"
+)
+
+// UnknownCommandError is returned for a command that is neither a built-in,
+// a script nor a local package binary
+type UnknownCommandError struct {
+	Name string
+	// Candidates are the scripts, binaries and built-ins that could have been meant
+	Candidates []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("unknown command %q: no built-in, script or binary in node_modules/.bin has that name", e.Name)
+}
+
+// WithBinDirs sets the node_modules/.bin directories searched for local
+// binaries, nearest first
+func WithBinDirs(dirs []string) Option {
+	return func(t *Translator) {
+		t.binDirs = dirs
+	}
+}
+
+// translateScript runs a package.json script, falling back to a local package
+// binary. Without a manifest every unknown command is assumed to be a script.
+func (t *Translator) translateScript(args []string) (*Command, error) {
+	name := args[0]
+	run := &Command{
+		Command: Commands.Run[t.packageManager],
+		Args:    args,
+	}
+
+	// Deno tasks live in deno.json, which the manifest does not cover
+	if t.manifest == nil || t.packageManager == detector.Deno {
+		run.rule("%s is not a built-in, assumed to be a script", name)
+		return run, nil
+	}
+	if script, ok := t.manifest.Scripts[name]; ok {
+		run.rule("%s is a script in package.json", name)
+		return t.runScript(run, script), nil
+	}
+
+	if path, ok := project.FindBinary(t.binDirs, name); ok {
+		cmd := t.translateBinary(path, args)
+		cmd.Rules = append([]string{fmt.Sprintf("%s is not a script, running %s", name, path)}, cmd.Rules...)
+		return cmd, nil
+	}
+
+	return nil, &UnknownCommandError{Name: name, Candidates: t.candidates()}
+}
+
+// translateRun runs a script named explicitly with `pm run`, which deno
+// spells `deno task`. Scripts missing from package.json are left for the
+// package manager to report.
+func (t *Translator) translateRun(args []string) *Command {
+	run := &Command{Command: Commands.Run[t.packageManager], Args: args}
+	if len(args) == 0 || t.manifest == nil || t.packageManager == detector.Deno {
+		return run
+	}
+
+	script, ok := t.manifest.Scripts[args[0]]
+	if !ok {
+		return run
+	}
+	run.rule("%s is a script in package.json", args[0])
+	return t.runScript(run, script)
+}
+
+// runScript applies the hook policy to run, which runs a package.json script
+// through the package manager, or replaces it by running script directly
+func (t *Translator) runScript(run *Command, script string) *Command {
+	name := run.Args[0]
+	if t.runsDirect() {
+		direct := t.translateDirect(name, script, run.Args[1:])
+		direct.Rules = append(run.Rules, direct.Rules...)
+		t.directHooks(direct, name)
+		return direct
+	}
+	t.applyHooks(run, name)
+	return run
+}
+
+// Script translates running the package.json script name, even when a
+// built-in command has the same name
+func (t *Translator) Script(name string, args ...string) (*Command, error) {
+	cmd, err := t.translateScript(append([]string{name}, args...))
+	if err != nil {
+		return nil, err
+	}
+	return t.finish(name, cmd)
+}
+
+// translateBinary runs a binary from node_modules/.bin through the package
+// manager, so it sees the same environment as it would in a script
+func (t *Translator) translateBinary(path string, args []string) *Command {
+	switch t.packageManager {
+	case detector.NPM:
+		if t.atLeast("7") {
+			return &Command{Command: []string{"exec", "--"}, Args: args}
+		}
+		cmd := &Command{Binary: "npx", Args: args}
+		cmd.rule("npm %s predates npm exec, using npx", t.version)
+		return cmd
+	case detector.Pnpm:
+		return &Command{Command: []string{"exec"}, Args: args}
+	case detector.Yarn, detector.YarnBerry, detector.Bun:
+		// run falls back to binaries on its own
+		return &Command{Command: Commands.Run[t.packageManager], Args: args}
+	}
+	return &Command{Binary: path, Args: args[1:]}
+}
+
+func (t *Translator) candidates() []string {
+	var candidates []string
+	for _, script := range t.manifest.OrderedScripts {
+		candidates = append(candidates, script.Name)
+	}
+	candidates = append(candidates, project.ListBinaries(t.binDirs)...)
+	candidates = append(candidates, t.builtIns()...)
+	return candidates
+}
+
+// builtIns lists the single word built-in commands available in the package manager version
+func (t *Translator) builtIns() []string {
+	var names []string
+	for _, cmd := range builtInCommands(t.packageManager) {
+		if len(cmd) == 1 && t.isAvailable(cmd[0]) {
+			names = append(names, cmd[0])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,280 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"pm/internal/detector"
+)
+
+// SpecKind is the form of a package specifier
+type SpecKind int
+
+const (
+	// SpecRegistry is a registry package, e.g. "react" or "react@^18"
+	SpecRegistry SpecKind = iota
+	// SpecAlias installs a registry package under another name, e.g. "old@npm:react@17"
+	SpecAlias
+	// SpecGit is a git repository, e.g. "github:user/repo#ref"
+	SpecGit
+	// SpecFile is a local directory or tarball, e.g. "file:../lib"
+	SpecFile
+	// SpecLink symlinks a local directory, e.g. "link:../lib"
+	SpecLink
+	// SpecTarball is a remote tarball URL
+	SpecTarball
+	// SpecWorkspace is a package of the same workspace, e.g. "ui@workspace:*"
+	SpecWorkspace
+	// SpecJSR is a package from the JSR registry, e.g. "jsr:@std/path"
+	SpecJSR
+)
+
+func (k SpecKind) String() string {
+	switch k {
+	case SpecRegistry:
+		return "registry"
+	case SpecAlias:
+		return "npm: alias"
+	case SpecGit:
+		return "git"
+	case SpecFile:
+		return "file:"
+	case SpecLink:
+		return "link:"
+	case SpecTarball:
+		return "tarball URL"
+	case SpecWorkspace:
+		return "workspace:"
+	case SpecJSR:
+		return "jsr:"
+	}
+	return "unknown"
+}
+
+// Spec is a parsed package specifier
+type Spec struct {
+	Raw  string
+	Kind SpecKind
+	// Name is the name the package is installed under. It may be empty for
+	// git, file, link and tarball specifiers, which name themselves.
+	Name string
+	// Range is the version range or tag; for aliases, the range of the real package
+	Range string
+	// Target is the real package of an alias, or the location of git, file,
+	// link, tarball and jsr specifiers
+	Target string
+}
+
+var (
+	packageNamePattern = regexp.MustCompile(`^(@[a-zA-Z0-9][a-zA-Z0-9._~-]*/)?[a-zA-Z0-9][a-zA-Z0-9._~-]*$`)
+	gitHostPrefixes    = []string{"github:", "gitlab:", "bitbucket:", "gist:"}
+	// githubShorthand matches "user/repo" and "user/repo#ref"
+	githubShorthand = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]*/[a-zA-Z0-9._-]+(#.*)?$`)
+)
+
+// ParseSpec parses a package specifier as accepted by `pm add`
+func ParseSpec(raw string) (Spec, error) {
+	// Only version ranges may have spaces, e.g. "react@>=16 <19"
+	if raw == "" || strings.TrimSpace(raw) != raw || strings.ContainsAny(raw, "\n\r") {
+		return Spec{}, fmt.Errorf("invalid package specifier %q", raw)
+	}
+
+	spec, err := parseLocation(raw)
+	if err != nil {
+		return Spec{}, err
+	}
+	if spec != nil {
+		if strings.ContainsAny(raw, " \t") {
+			return Spec{}, fmt.Errorf("invalid package specifier %q", raw)
+		}
+		return *spec, nil
+	}
+
+	name, rest, hasRest := splitNameRange(raw)
+	if !packageNamePattern.MatchString(name) || len(name) > 214 {
+		return Spec{}, fmt.Errorf("invalid package name %q in %q", name, raw)
+	}
+	if !hasRest {
+		return Spec{Raw: raw, Kind: SpecRegistry, Name: name}, nil
+	}
+	if rest == "" {
+		return Spec{}, fmt.Errorf("missing version after @ in %q", raw)
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "npm:"):
+		real, err := ParseSpec(strings.TrimPrefix(rest, "npm:"))
+		if err != nil || real.Kind != SpecRegistry {
+			return Spec{}, fmt.Errorf("invalid npm: alias %q", raw)
+		}
+		return Spec{Raw: raw, Kind: SpecAlias, Name: name, Target: real.Name, Range: real.Range}, nil
+	case strings.HasPrefix(rest, "workspace:"):
+		return Spec{Raw: raw, Kind: SpecWorkspace, Name: name, Range: strings.TrimPrefix(rest, "workspace:")}, nil
+	}
+
+	// A named location, e.g. "lib@file:../lib"
+	if location, err := parseLocation(rest); err != nil {
+		return Spec{}, err
+	} else if location != nil {
+		if strings.ContainsAny(rest, " \t") {
+			return Spec{}, fmt.Errorf("invalid package specifier %q", raw)
+		}
+		if location.Kind == SpecJSR {
+			return Spec{}, fmt.Errorf("jsr: packages cannot be renamed: %q", raw)
+		}
+		location.Raw = raw
+		location.Name = name
+		return *location, nil
+	}
+
+	return Spec{Raw: raw, Kind: SpecRegistry, Name: name, Range: rest}, nil
+}
+
+// parseLocation parses the specifiers that are not registry packages,
+// returning nil when value is a package name
+func parseLocation(value string) (*Spec, error) {
+	spec := &Spec{Raw: value, Target: value}
+
+	switch {
+	case strings.HasPrefix(value, "npm:"):
+		// A bare npm: prefix only names the registry, as deno spells it
+		real, err := ParseSpec(strings.TrimPrefix(value, "npm:"))
+		if err != nil || real.Kind != SpecRegistry {
+			return nil, fmt.Errorf("invalid npm: specifier %q", value)
+		}
+		real.Raw = value
+		return &real, nil
+	case strings.HasPrefix(value, "jsr:"):
+		spec.Kind = SpecJSR
+	case strings.HasPrefix(value, "workspace:"):
+		return nil, fmt.Errorf("workspace: needs a package name, e.g. name@%s", value)
+	case strings.HasPrefix(value, "file:"):
+		spec.Kind = SpecFile
+		spec.Target = strings.TrimPrefix(value, "file:")
+	case strings.HasPrefix(value, "link:"):
+		spec.Kind = SpecLink
+		spec.Target = strings.TrimPrefix(value, "link:")
+	case strings.HasPrefix(value, "git+"), strings.HasPrefix(value, "git://"), strings.HasPrefix(value, "git@"):
+		spec.Kind = SpecGit
+	case strings.HasPrefix(value, "http://"), strings.HasPrefix(value, "https://"):
+		spec.Kind = SpecTarball
+		if strings.HasSuffix(strings.SplitN(value, "#", 2)[0], ".git") {
+			spec.Kind = SpecGit
+		}
+	case hasGitHostPrefix(value):
+		spec.Kind = SpecGit
+	case strings.HasPrefix(value, "."), strings.HasPrefix(value, "/"), strings.HasPrefix(value, "~/"), isWindowsPath(value):
+		spec.Kind = SpecFile
+	case githubShorthand.MatchString(value):
+		spec.Kind = SpecGit
+		spec.Target = "github:" + value
+	default:
+		return nil, nil
+	}
+
+	if spec.Target == "" {
+		return nil, fmt.Errorf("missing location in %q", value)
+	}
+	return spec, nil
+}
+
+// splitNameRange splits "name@range" and "@scope/name@range"
+func splitNameRange(raw string) (string, string, bool) {
+	at := strings.Index(strings.TrimPrefix(raw, "@"), "@")
+	if at < 0 {
+		return raw, "", false
+	}
+	if strings.HasPrefix(raw, "@") {
+		at++
+	}
+	return raw[:at], raw[at+1:], true
+}
+
+func hasGitHostPrefix(value string) bool {
+	for _, prefix := range gitHostPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWindowsPath(value string) bool {
+	return len(value) > 2 && value[1] == ':' && (value[2] == '\\' || value[2] == '/')
+}
+
+// renderSpec spells a specifier the way the package manager expects it
+func (t *Translator) renderSpec(spec Spec) (string, error) {
+	unsupported := func() (string, error) {
+		return "", fmt.Errorf("cannot add %q: %s specifiers are not supported by %s", spec.Raw, spec.Kind, t.packageManager)
+	}
+	named := func(location string) string {
+		if spec.Name == "" {
+			return location
+		}
+		return spec.Name + "@" + location
+	}
+
+	switch spec.Kind {
+	case SpecRegistry:
+		value := spec.Name
+		if spec.Range != "" {
+			value += "@" + spec.Range
+		}
+		if t.packageManager == detector.Deno {
+			// Deno resolves bare names from its own registry, so npm packages need a prefix
+			return "npm:" + value, nil
+		}
+		return value, nil
+
+	case SpecAlias:
+		if t.packageManager == detector.Deno || (t.packageManager == detector.NPM && !t.atLeast("6.9")) {
+			return unsupported()
+		}
+		value := spec.Name + "@npm:" + spec.Target
+		if spec.Range != "" {
+			value += "@" + spec.Range
+		}
+		return value, nil
+
+	case SpecWorkspace:
+		switch t.packageManager {
+		case detector.Pnpm, detector.YarnBerry, detector.Bun:
+			return spec.Name + "@workspace:" + spec.Range, nil
+		}
+		return unsupported()
+
+	case SpecGit, SpecTarball:
+		if t.packageManager == detector.Deno {
+			return unsupported()
+		}
+		return named(spec.Target), nil
+
+	case SpecFile:
+		if t.packageManager == detector.Deno {
+			return unsupported()
+		}
+		return named("file:" + spec.Target), nil
+
+	case SpecLink:
+		switch t.packageManager {
+		case detector.NPM:
+			// npm links local directories given with file:
+			return named("file:" + spec.Target), nil
+		case detector.Deno:
+			return unsupported()
+		}
+		return named("link:" + spec.Target), nil
+
+	case SpecJSR:
+		switch {
+		case t.packageManager == detector.Deno,
+			t.packageManager == detector.Pnpm && t.atLeast("10.9"),
+			t.packageManager == detector.YarnBerry && t.atLeast("4.9"):
+			return spec.Target, nil
+		}
+		return unsupported()
+	}
+	return unsupported()
+}
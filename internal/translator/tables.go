@@ -0,0 +1,313 @@
+package translator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"pm/internal/detector"
+	"pm/internal/jsonc"
+	"pm/internal/semver"
+)
+
+//go:embed tables.json
+var embeddedTables []byte
+
+// Tables describe how pm's commands and flags are spelled by each package
+// manager. The built-in tables are embedded from tables.json; Extend layers
+// entries from user configuration on top of them.
+type Tables struct {
+	// Commands maps pm commands, e.g. "add", onto each package manager
+	Commands map[string]Translation `json:"commands"`
+	// Flags maps long flags, e.g. "save-dev", onto each package manager.
+	// Flags without an entry are passed through unchanged.
+	Flags map[string]Translation `json:"flags"`
+	// ValueFlags lists the long flags that take a value
+	ValueFlags []string `json:"valueFlags"`
+	// ShortFlags expands single letter flags to long flags, e.g. "D" to "save-dev"
+	ShortFlags map[string]string `json:"shortFlags"`
+	// BuiltIns lists the commands each package manager runs itself, with
+	// subcommands separated by spaces, e.g. "cache clean"
+	BuiltIns map[detector.PackageManager][]string `json:"builtIns"`
+	// BuiltInSince gives the first version a built-in command exists in
+	BuiltInSince map[detector.PackageManager]map[string]string `json:"builtInSince"`
+	// KnownFlags lists the long flags each package manager accepts
+	KnownFlags map[detector.PackageManager][]string `json:"knownFlags"`
+}
+
+// Translation maps each package manager onto the rules spelling a command or flag
+type Translation map[detector.PackageManager]Rules
+
+// Rules are tried in order; the first that applies is used
+type Rules []Rule
+
+// Rule spells a command or flag for a package manager
+type Rule struct {
+	// Since is the first package manager version the rule applies to
+	Since string `json:"since,omitempty"`
+	// Value restricts the rule to one flag value
+	Value string `json:"value,omitempty"`
+	// Args is the spelling; "{value}" is replaced by the flag value
+	Args []string `json:"args"`
+	// Note is recorded as a translation rule when the rule is used;
+	// "{version}" is replaced by the package manager version
+	Note string `json:"note,omitempty"`
+}
+
+// UnmarshalJSON accepts a list of rules, or the words of a single
+// unconditional rule, e.g. ["--dev"]
+func (r *Rules) UnmarshalJSON(data []byte) error {
+	var words []string
+	if err := json.Unmarshal(data, &words); err == nil {
+		*r = Rules{{Args: words}}
+		if len(words) == 0 {
+			*r = Rules{}
+		}
+		return nil
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("expected a list of words or rules: %w", err)
+	}
+	*r = rules
+	return nil
+}
+
+// tables are the translation tables in use
+var tables = mustParseTables(embeddedTables)
+
+func mustParseTables(data []byte) *Tables {
+	parsed, err := ParseTables(data)
+	if err != nil {
+		panic(fmt.Sprintf("translator: invalid embedded tables: %v", err))
+	}
+	return parsed
+}
+
+// ParseTables parses translation tables in the layout of tables.json.
+// Comments are allowed.
+func ParseTables(data []byte) (*Tables, error) {
+	parsed := &Tables{}
+	if err := json.Unmarshal(jsonc.Strip(data), parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// Extend merges translation tables from user configuration into the tables
+// in use. Entries replace the built-in entry for the same command, flag and
+// package manager; lists are added to.
+func Extend(data []byte) error {
+	overrides, err := ParseTables(data)
+	if err != nil {
+		return fmt.Errorf("invalid translations: %w", err)
+	}
+
+	tables.merge(overrides)
+	Commands = newCommands(tables)
+	return nil
+}
+
+// Current returns a copy of the translation tables in use
+func Current() *Tables {
+	current := &Tables{}
+	current.merge(tables)
+	return current
+}
+
+func (t *Tables) merge(other *Tables) {
+	mergeTranslations(&t.Commands, other.Commands)
+	mergeTranslations(&t.Flags, other.Flags)
+	t.ValueFlags = appendMissing(t.ValueFlags, other.ValueFlags...)
+
+	if t.ShortFlags == nil {
+		t.ShortFlags = map[string]string{}
+	}
+	for short, long := range other.ShortFlags {
+		t.ShortFlags[short] = long
+	}
+
+	if t.BuiltIns == nil {
+		t.BuiltIns = map[detector.PackageManager][]string{}
+	}
+	for pm, commands := range other.BuiltIns {
+		t.BuiltIns[pm] = appendMissing(t.BuiltIns[pm], commands...)
+	}
+
+	if t.BuiltInSince == nil {
+		t.BuiltInSince = map[detector.PackageManager]map[string]string{}
+	}
+	for pm, versions := range other.BuiltInSince {
+		if t.BuiltInSince[pm] == nil {
+			t.BuiltInSince[pm] = map[string]string{}
+		}
+		for command, since := range versions {
+			t.BuiltInSince[pm][command] = since
+		}
+	}
+
+	if t.KnownFlags == nil {
+		t.KnownFlags = map[detector.PackageManager][]string{}
+	}
+	for pm, flags := range other.KnownFlags {
+		t.KnownFlags[pm] = appendMissing(t.KnownFlags[pm], flags...)
+	}
+}
+
+func mergeTranslations(into *map[string]Translation, from map[string]Translation) {
+	if *into == nil {
+		*into = map[string]Translation{}
+	}
+	for name, translation := range from {
+		merged := Translation{}
+		for pm, rules := range (*into)[name] {
+			merged[pm] = rules
+		}
+		for pm, rules := range translation {
+			merged[pm] = rules
+		}
+		(*into)[name] = merged
+	}
+}
+
+func appendMissing(list []string, values ...string) []string {
+	list = slices.Clone(list)
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// codedCommands are the pm commands and aliases whose translation is written
+// in code rather than read from the tables
+var codedCommands = []string{"i", "rm", "remove", "un", "update", "upgrade", "dlx", "x", "run-script"}
+
+// Validate reports entries that miss a package manager, name unknown
+// package managers or carry versions that cannot be compared
+func (t *Tables) Validate() []string {
+	var problems []string
+	report := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	validateTranslations := func(kind string, translations map[string]Translation) {
+		for _, name := range sortedKeys(translations) {
+			translation := translations[name]
+			for _, pm := range detector.Supported {
+				if _, ok := translation[pm]; !ok {
+					report("%s %q has no entry for %s", kind, name, pm)
+				}
+			}
+			for _, pm := range sortedKeys(translation) {
+				if !slices.Contains(detector.Supported, pm) {
+					report("%s %q names unknown package manager %q", kind, name, pm)
+				}
+				for _, rule := range translation[pm] {
+					if rule.Since != "" && !validVersion(rule.Since) {
+						report("%s %q for %s has invalid version %q", kind, name, pm, rule.Since)
+					}
+				}
+			}
+		}
+	}
+	validateTranslations("command", t.Commands)
+	validateTranslations("flag", t.Flags)
+
+	for _, name := range []string{"add", "ci", "install", "uninstall", "run"} {
+		if _, ok := t.Commands[name]; !ok {
+			report("command %q is missing", name)
+		}
+	}
+	for _, name := range sortedKeys(t.Commands) {
+		if slices.Contains(codedCommands, name) {
+			report("command %q is translated by pm itself; its entry is ignored", name)
+		}
+	}
+
+	for _, short := range sortedKeys(t.ShortFlags) {
+		if len(short) != 1 {
+			report("short flag %q is not a single letter", short)
+		}
+	}
+
+	for _, pm := range detector.Supported {
+		if len(t.BuiltIns[pm]) == 0 {
+			report("builtIns has no entry for %s", pm)
+		}
+		if len(t.KnownFlags[pm]) == 0 {
+			report("knownFlags has no entry for %s", pm)
+		}
+	}
+	for _, pm := range sortedKeys(t.BuiltInSince) {
+		versions := t.BuiltInSince[pm]
+		for _, command := range sortedKeys(versions) {
+			if !slices.Contains(t.BuiltIns[pm], command) {
+				report("builtInSince names %q, which is not a %s built-in", command, pm)
+			}
+			if !validVersion(versions[command]) {
+				report("builtInSince %q for %s has invalid version %q", command, pm, versions[command])
+			}
+		}
+	}
+
+	return problems
+}
+
+// validVersion reports whether version is a version number, possibly
+// without minor or patch, e.g. "7" or "7.20"
+func validVersion(version string) bool {
+	for strings.Count(version, ".") < 2 {
+		version += ".0"
+	}
+	_, err := semver.Parse(version)
+	return err == nil
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// supportsFlag reports whether the package manager accepts a long flag
+func (t *Translator) supportsFlag(name string) bool {
+	return slices.Contains(tables.KnownFlags[t.packageManager], name)
+}
+
+// resolve picks the first rule that applies to the package manager version
+// and flag value, returning the spelling and the note to record
+func (t *Translator) resolve(translation Translation, value string) ([]string, string, bool) {
+	for _, rule := range translation[t.packageManager] {
+		if rule.Since != "" && !t.atLeast(rule.Since) {
+			continue
+		}
+		if rule.Value != "" && rule.Value != value {
+			continue
+		}
+
+		args := make([]string, len(rule.Args))
+		for i, arg := range rule.Args {
+			args[i] = strings.ReplaceAll(arg, "{value}", value)
+		}
+		return args, strings.ReplaceAll(rule.Note, "{version}", t.version), true
+	}
+	return nil, "", false
+}
+
+// command spells a pm command for the package manager, recording the note
+// of the rule that applied
+func (t *Translator) command(cmd *Command, name string) []string {
+	args, note, _ := t.resolve(tables.Commands[name], "")
+	if note != "" {
+		cmd.rule("%s", note)
+	}
+	return args
+}
@@ -1,35 +1,100 @@
 package translator
 
 import (
+	"fmt"
 	"pm/internal/detector"
+	"pm/internal/project"
+	"slices"
 	"strings"
 )
 
 // Translator translates universal package manager commands to package-manager-specific commands
 type Translator struct {
 	packageManager detector.PackageManager
+	version        string
+	manifest       *project.PackageJSON
+	binDirs        []string
+	strict         bool
+	hooks          HookPolicy
+	direct         bool
+	packageDir     string
 }
 
 // New creates a new command translator for the given package manager
-func New(pm detector.PackageManager) *Translator {
-	return &Translator{
+func New(pm detector.PackageManager, opts ...Option) *Translator {
+	t := &Translator{
 		packageManager: pm,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 // Command represents a translated command with its flags and arguments
 type Command struct {
+	// Binary replaces the package manager executable when set, e.g. "npx"
+	Binary string
+	// Prefix holds global options that precede the command, e.g. "--filter web"
+	Prefix []string
+	// Dir runs the command in another directory when set
+	Dir     string
 	Command []string
 	Flags   []string
 	Args    []string
+	// Before and After run around the command, e.g. the pre and post
+	// scripts of a package manager that skips them
+	Before []*Command
+	After  []*Command
+	// Env is added to the environment the command runs in
+	Env []string
+	// Rules describes the translation rules that fired, for pm --dry-run
+	Rules []string
+	// Warnings describes flags that were dropped or passed through without
+	// being known to the package manager
+	Warnings []string
+}
+
+// rule records a translation rule that fired
+func (c *Command) rule(format string, args ...any) {
+	c.Rules = append(c.Rules, fmt.Sprintf(format, args...))
+}
+
+// warn records a flag that may leave the command weaker than intended
+func (c *Command) warn(format string, args ...any) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
 }
 
 // Translate translates a universal command to a package-manager-specific command
-func (t *Translator) Translate(packageManager detector.PackageManager, args []string) *Command {
+func (t *Translator) Translate(packageManager detector.PackageManager, args []string) (*Command, error) {
 	if len(args) == 0 {
-		return &Command{}
+		return &Command{}, nil
+	}
+
+	cmd, err := t.translate(packageManager, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.finish(args[0], cmd)
+}
+
+// finish applies strict mode to a translated command and records how the
+// command given as name was mapped
+func (t *Translator) finish(name string, cmd *Command) (*Command, error) {
+	if t.strict && len(cmd.Warnings) > 0 {
+		return nil, fmt.Errorf("strict mode: %s", strings.Join(cmd.Warnings, "; "))
+	}
+
+	binary := cmd.Binary
+	if binary == "" {
+		binary = t.packageManager.Binary()
 	}
+	mapping := strings.TrimSpace(binary + " " + strings.Join(cmd.Command, " "))
+	cmd.Rules = append([]string{fmt.Sprintf("%s → %s", name, mapping)}, cmd.Rules...)
+	return cmd, nil
+}
 
+func (t *Translator) translate(packageManager detector.PackageManager, args []string) (*Command, error) {
 	baseCommand := args[0]
 	remainingArgs := args[1:]
 
@@ -39,146 +104,160 @@ func (t *Translator) Translate(packageManager detector.PackageManager, args []st
 	case "add":
 		return t.translateAdd(remainingArgs)
 	case "rm", "remove", "uninstall", "un":
-		return t.translateUninstall(remainingArgs)
+		return t.translateUninstall(remainingArgs), nil
 	case "ci":
-		return t.translateCI(remainingArgs)
+		return t.translateCI(remainingArgs), nil
+	case "update", "upgrade":
+		return t.translateUpdate(remainingArgs), nil
+	case "dlx", "x":
+		return t.translateDlx(remainingArgs)
+	case "run", "run-script":
+		return t.translateRun(remainingArgs), nil
 	default:
-		if IsBuiltIn(packageManager, baseCommand) {
-			return &Command{
+		if _, ok := tables.Commands[baseCommand]; ok {
+			return t.translateTable(baseCommand, remainingArgs)
+		}
+		if IsBuiltIn(packageManager, baseCommand) && t.isAvailable(baseCommand) {
+			cmd := &Command{
 				Command: []string{baseCommand},
 				Args:    remainingArgs,
 			}
+			cmd.rule("%s is a %s built-in, passed through", baseCommand, t.packageManager)
+			return cmd, nil
 		}
-		return &Command{
-			Command: []string{"run"},
-			Args:    args,
-		}
+		return t.translateScript(args)
 	}
 }
 
-func (t *Translator) translateInstall(args []string) *Command {
+func (t *Translator) translateInstall(args []string) (*Command, error) {
 	parsed := t.parseArgs(args)
 
-	if len(parsed.packages) > 0 {
+	if len(parsed.packages()) > 0 {
 		return t.translateAdd(args)
 	}
 
-	var command []string
-	switch t.packageManager {
-	case detector.NPM:
-		command = []string{"install"}
-	case detector.Yarn, detector.YarnBerry:
-		command = []string{"install"}
-	case detector.Pnpm:
-		command = []string{"install"}
-	case detector.Bun:
-		command = []string{"install"}
+	cmd := &Command{}
+	cmd.Command = t.command(cmd, "install")
+	if err := t.translateTokens(cmd, parsed, nil); err != nil {
+		return nil, err
 	}
+	return cmd, nil
+}
 
-	flags := t.translateInstallFlags(parsed.flags)
+func (t *Translator) translateAdd(args []string) (*Command, error) {
+	parsed := t.parseArgs(args)
 
-	return &Command{
-		Command: command,
-		Flags:   flags,
-		Args:    parsed.packages,
+	cmd := &Command{}
+	cmd.Command = t.command(cmd, "add")
+	if err := t.translateTokens(cmd, parsed, t.translatePackage); err != nil {
+		return nil, err
 	}
+	return cmd, nil
 }
 
-func (t *Translator) translateAdd(args []string) *Command {
-	parsed := t.parseArgs(args)
+func (t *Translator) translateUninstall(args []string) *Command {
+	cmd, _ := t.translateTable("uninstall", args)
+	return cmd
+}
+
+func (t *Translator) translateCI(args []string) *Command {
+	cmd, _ := t.translateTable("ci", args)
+	return cmd
+}
 
-	var command []string
-	switch t.packageManager {
-	case detector.NPM:
-		command = []string{"install"}
-	case detector.Yarn, detector.YarnBerry:
-		command = []string{"add"}
-	case detector.Pnpm:
-		command = []string{"add"}
-	case detector.Bun:
-		command = []string{"add"}
+// translateTable spells a command from the tables, translating its flags and
+// keeping its positional arguments. Commands added through configuration
+// are translated this way.
+func (t *Translator) translateTable(name string, args []string) (*Command, error) {
+	if _, _, ok := t.resolve(tables.Commands[name], ""); !ok {
+		return nil, fmt.Errorf("%s has no equivalent of %s", t.packageManager, name)
 	}
 
-	flags := t.translateAddFlags(parsed.flags)
+	cmd := &Command{}
+	cmd.Command = t.command(cmd, name)
+	// Without a positional translation, the arguments translate without errors
+	_ = t.translateTokens(cmd, t.parseArgs(args), nil)
+	return cmd, nil
+}
 
-	return &Command{
-		Command: command,
-		Flags:   flags,
-		Args:    parsed.packages,
-	}
+// token is a single flag or positional argument of a command line
+type token struct {
+	// flag is the long flag name without dashes, empty for positional arguments
+	flag string
+	// value is the flag value, or the positional argument itself
+	value string
+	// short marks a single letter flag pm has no long name for
+	short bool
 }
 
-func (t *Translator) translateUninstall(args []string) *Command {
-	parsed := t.parseArgs(args)
+func (tk token) isFlag() bool {
+	return tk.flag != ""
+}
 
-	var command []string
-	switch t.packageManager {
-	case detector.NPM:
-		command = []string{"uninstall"}
-	case detector.Yarn, detector.YarnBerry:
-		command = []string{"remove"}
-	case detector.Pnpm:
-		command = []string{"remove"}
-	case detector.Bun:
-		command = []string{"remove"}
+// passThrough spells a flag the way it was given, in its long form where pm knows one
+func (tk token) passThrough() []string {
+	dashes := "--"
+	if tk.short {
+		dashes = "-"
 	}
-
-	flags := t.translateUninstallFlags(parsed.flags)
-
-	return &Command{
-		Command: command,
-		Flags:   flags,
-		Args:    parsed.packages,
+	if tk.value != "" {
+		return []string{dashes + tk.flag, tk.value}
 	}
+	return []string{dashes + tk.flag}
 }
 
-func (t *Translator) translateCI(args []string) *Command {
-	var command []string
-	switch t.packageManager {
-	case detector.NPM:
-		command = []string{"ci"}
-	case detector.Yarn:
-		command = []string{"install", "--frozen-lockfile"}
-	case detector.YarnBerry:
-		command = []string{"install", "--immutable"}
-	case detector.Pnpm:
-		command = []string{"install", "--frozen-lockfile"}
-	case detector.Bun:
-		command = []string{"install", "--frozen-lockfile"}
-	}
+// parsedArgs holds the tokens of a command line in their original order
+type parsedArgs struct {
+	tokens []token
+	// rest is "--" and every argument after it, kept verbatim
+	rest []string
+}
 
-	return &Command{
-		Command: command,
-		Args:    args,
+// packages returns the positional arguments in order
+func (p *parsedArgs) packages() []string {
+	packages := []string{}
+	for _, tk := range p.tokens {
+		if !tk.isFlag() {
+			packages = append(packages, tk.value)
+		}
 	}
+	return packages
 }
 
-type parsedArgs struct {
-	packages []string
-	flags    map[string]string
+// flags returns the flags in order, repeated flags included
+func (p *parsedArgs) flags() []token {
+	var flags []token
+	for _, tk := range p.tokens {
+		if tk.isFlag() {
+			flags = append(flags, tk)
+		}
+	}
+	return flags
 }
 
 func (t *Translator) parseArgs(args []string) *parsedArgs {
-	parsed := &parsedArgs{
-		packages: []string{},
-		flags:    make(map[string]string),
-	}
+	parsed := &parsedArgs{}
 
 	i := 0
 	for i < len(args) {
 		arg := args[i]
 
+		if arg == "--" {
+			parsed.rest = args[i:]
+			break
+		}
+
 		if strings.HasPrefix(arg, "--") {
 			flagName := strings.TrimPrefix(arg, "--")
 
 			if strings.Contains(flagName, "=") {
 				parts := strings.SplitN(flagName, "=", 2)
-				parsed.flags[parts[0]] = parts[1]
+				parsed.tokens = append(parsed.tokens, token{flag: parts[0], value: parts[1]})
 			} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") && t.isFlagWithValue(flagName) {
-				parsed.flags[flagName] = args[i+1]
+				parsed.tokens = append(parsed.tokens, token{flag: flagName, value: args[i+1]})
 				i++
 			} else {
-				parsed.flags[flagName] = ""
+				parsed.tokens = append(parsed.tokens, token{flag: flagName})
 			}
 		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 && !strings.HasPrefix(arg, "--") {
 			flagName := strings.TrimPrefix(arg, "-")
@@ -187,17 +266,18 @@ func (t *Translator) parseArgs(args []string) *parsedArgs {
 			for j, char := range flagName {
 				shortFlag := string(char)
 				fullFlag := t.expandShortFlag(shortFlag)
+				short := fullFlag == shortFlag
 
 				// Only the last flag in a group can have a value
 				if j == len(flagName)-1 && t.isFlagWithValue(fullFlag) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-					parsed.flags[fullFlag] = args[i+1]
+					parsed.tokens = append(parsed.tokens, token{flag: fullFlag, value: args[i+1], short: short})
 					i++
 				} else {
-					parsed.flags[fullFlag] = ""
+					parsed.tokens = append(parsed.tokens, token{flag: fullFlag, short: short})
 				}
 			}
 		} else {
-			parsed.packages = append(parsed.packages, arg)
+			parsed.tokens = append(parsed.tokens, token{value: arg})
 		}
 
 		i++
@@ -206,234 +286,118 @@ func (t *Translator) parseArgs(args []string) *parsedArgs {
 	return parsed
 }
 
-func (t *Translator) isFlagWithValue(flag string) bool {
-	flagsWithValues := []string{
-		"omit", "registry", "tag", "workspace", "workspaces",
-		"production", "only", "also", "save-bundle", "save-exact",
-		"loglevel", "logs-max", "logs-dir", "script-shell",
-		"cache-folder", "cache-dir", "prefix", "userconfig",
-	}
-
-	for _, f := range flagsWithValues {
-		if f == flag {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (t *Translator) expandShortFlag(short string) string {
-	shortFlagMap := map[string]string{
-		"D": "save-dev",
-		"P": "save-peer",
-		"O": "save-optional",
-		"E": "save-exact",
-		"g": "global",
-		"S": "save",
-		"B": "save-bundle",
-		"f": "force",
-		"s": "silent",
-		"d": "loglevel",
-	}
-
-	if full, ok := shortFlagMap[short]; ok {
-		return full
-	}
-
-	return short
-}
-
-func (t *Translator) translateInstallFlags(flags map[string]string) []string {
-	var translated []string
-
-	for flag, value := range flags {
-		switch flag {
-		case "frozen-lockfile":
-			translated = append(translated, t.translateFrozenLockfileFlag()...)
-		case "omit":
-			translated = append(translated, t.translateOmitFlag(value)...)
-		case "global", "g":
-			translated = append(translated, t.translateGlobalFlag()...)
-		case "production":
-			translated = append(translated, t.translateProductionFlag()...)
-		default:
-			if value != "" {
-				translated = append(translated, "--"+flag, value)
-			} else {
-				translated = append(translated, "--"+flag)
+// translateTokens translates flags and positional arguments into cmd in their
+// original order. Flags before the first positional argument become the
+// command flags; later flags keep their place among the arguments. A flag
+// translating to the same spelling as an earlier one is dropped. A nil
+// translatePositional keeps positional arguments as they are. Arguments after
+// "--" are kept verbatim.
+func (t *Translator) translateTokens(cmd *Command, parsed *parsedArgs, translatePositional func(string) (string, error)) error {
+	args := []string{}
+	seen := map[string]bool{}
+
+	for _, tk := range parsed.tokens {
+		if !tk.isFlag() {
+			value := tk.value
+			if translatePositional != nil {
+				var err error
+				if value, err = translatePositional(value); err != nil {
+					return err
+				}
+				if value != tk.value {
+					cmd.rule("%s → %s", tk.value, value)
+				}
 			}
+			args = append(args, value)
+			continue
 		}
-	}
-
-	return translated
-}
 
-func (t *Translator) translateAddFlags(flags map[string]string) []string {
-	var translated []string
-
-	for flag, value := range flags {
-		switch flag {
-		case "save-dev", "D":
-			translated = append(translated, t.translateDevFlag()...)
-		case "save-peer", "P":
-			translated = append(translated, t.translatePeerFlag()...)
-		case "save-optional", "O":
-			translated = append(translated, t.translateOptionalFlag()...)
-		case "save-exact", "E":
-			translated = append(translated, t.translateExactFlag()...)
-		case "global", "g":
-			translated = append(translated, t.translateGlobalFlag()...)
-		case "omit":
-			translated = append(translated, t.translateOmitFlag(value)...)
-		default:
-			if value != "" {
-				translated = append(translated, "--"+flag, value)
-			} else {
-				translated = append(translated, "--"+flag)
-			}
+		original := strings.Join(tk.passThrough(), " ")
+		translated, implied := t.translateFlag(cmd, tk)
+		if implied {
+			cmd.rule("%s dropped: %s does this by default", original, t.packageManager)
+			continue
+		}
+		if len(translated) == 0 {
+			cmd.rule("%s dropped: %s has no equivalent", original, t.packageManager)
+			cmd.warn("%s dropped: %s has no equivalent", original, t.packageManager)
+			continue
+		}
+		if slices.Equal(translated, tk.passThrough()) {
+			t.warnUnknownFlag(cmd, tk)
+		}
+		key := strings.Join(translated, "\x00")
+		if seen[key] {
+			cmd.rule("%s dropped: repeats %s", original, strings.Join(translated, " "))
+			continue
+		}
+		seen[key] = true
+		if spelled := strings.Join(translated, " "); spelled != original {
+			cmd.rule("%s → %s", original, spelled)
 		}
-	}
-
-	return translated
-}
 
-func (t *Translator) translateUninstallFlags(flags map[string]string) []string {
-	var translated []string
-
-	for flag, value := range flags {
-		switch flag {
-		case "save-dev", "D":
-			translated = append(translated, t.translateDevFlag()...)
-		case "global", "g":
-			translated = append(translated, t.translateGlobalFlag()...)
-		default:
-			if value != "" {
-				translated = append(translated, "--"+flag, value)
-			} else {
-				translated = append(translated, "--"+flag)
-			}
+		if len(args) == 0 {
+			cmd.Flags = append(cmd.Flags, translated...)
+		} else {
+			args = append(args, translated...)
 		}
 	}
 
-	return translated
-}
-
-func (t *Translator) translateFrozenLockfileFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{}
-	case detector.YarnBerry:
-		return []string{"--immutable"}
-	case detector.Yarn, detector.Pnpm, detector.Bun:
-		return []string{"--frozen-lockfile"}
-	}
-	return []string{}
+	cmd.Args = append(args, parsed.rest...)
+	return nil
 }
 
-func (t *Translator) translateDevFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--save-dev"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--dev"}
-	case detector.Pnpm:
-		return []string{"--save-dev"}
-	case detector.Bun:
-		return []string{"--dev"}
+// warnUnknownFlag warns about a flag passed through unchanged that the
+// package manager is not known to accept
+func (t *Translator) warnUnknownFlag(cmd *Command, tk token) {
+	if !t.supportsFlag(tk.flag) {
+		cmd.warn("%s passed through unchanged: not a known %s flag", strings.Join(tk.passThrough(), " "), t.packageManager)
 	}
-	return []string{}
 }
 
-func (t *Translator) translatePeerFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--save-peer"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--peer"}
-	case detector.Pnpm:
-		return []string{"--save-peer"}
-	case detector.Bun:
-		return []string{"--peer"}
+// passThroughFlags adds flags pm has no translation for to cmd unchanged,
+// warning about the ones the package manager is not known to accept
+func (t *Translator) passThroughFlags(cmd *Command, flags []token) {
+	for _, tk := range flags {
+		t.warnUnknownFlag(cmd, tk)
+		cmd.Flags = append(cmd.Flags, tk.passThrough()...)
 	}
-	return []string{}
 }
 
-func (t *Translator) translateOptionalFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--save-optional"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--optional"}
-	case detector.Pnpm:
-		return []string{"--save-optional"}
-	case detector.Bun:
-		return []string{"--optional"}
-	}
-	return []string{}
+func (t *Translator) isFlagWithValue(flag string) bool {
+	return slices.Contains(tables.ValueFlags, flag)
 }
 
-func (t *Translator) translateExactFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--save-exact"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--exact"}
-	case detector.Pnpm:
-		return []string{"--save-exact"}
-	case detector.Bun:
-		return []string{"--exact"}
+func (t *Translator) expandShortFlag(short string) string {
+	if full, ok := tables.ShortFlags[short]; ok {
+		return full
 	}
-	return []string{}
+	return short
 }
 
-func (t *Translator) translateGlobalFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--global"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--global"}
-	case detector.Pnpm:
-		return []string{"--global"}
-	case detector.Bun:
-		return []string{"--global"}
+// translateFlag spells a flag for the package manager. Flags without a
+// translation pass through unchanged; an empty result drops the flag.
+// implied reports a rule without words: the package manager does what the
+// flag asks for without it.
+func (t *Translator) translateFlag(cmd *Command, flag token) (translated []string, implied bool) {
+	translation, ok := tables.Flags[flag.flag]
+	if !ok || flag.short {
+		return flag.passThrough(), false
 	}
-	return []string{}
-}
 
-func (t *Translator) translateProductionFlag() []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--production"}
-	case detector.Yarn, detector.YarnBerry:
-		return []string{"--production"}
-	case detector.Pnpm:
-		return []string{"--prod"}
-	case detector.Bun:
-		return []string{"--production"}
+	translated, note, matched := t.resolve(translation, flag.value)
+	if note != "" {
+		cmd.rule("%s", note)
 	}
-	return []string{}
+	return translated, matched && len(translated) == 0
 }
 
-func (t *Translator) translateOmitFlag(value string) []string {
-	switch t.packageManager {
-	case detector.NPM:
-		return []string{"--omit", value}
-	case detector.Yarn, detector.YarnBerry:
-		if value == "dev" {
-			return []string{"--production"}
-		}
-		return []string{}
-	case detector.Pnpm:
-		if value == "dev" {
-			return []string{"--prod"}
-		}
-		return []string{"--omit", value}
-	case detector.Bun:
-		if value == "dev" {
-			return []string{"--production"}
-		}
-		return []string{}
+// translatePackage parses a package specifier and rewrites it into the form
+// the package manager expects, failing on forms it cannot install
+func (t *Translator) translatePackage(pkg string) (string, error) {
+	spec, err := ParseSpec(pkg)
+	if err != nil {
+		return "", err
 	}
-	return []string{}
+	return t.renderSpec(spec)
 }
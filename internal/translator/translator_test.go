@@ -1,10 +1,15 @@
 package translator
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	"pm/internal/detector"
+	"pm/internal/project"
 )
 
 func TestTranslator(t *testing.T) {
@@ -14,31 +19,55 @@ func TestTranslator(t *testing.T) {
 		input          []string
 		expected       string
 	}{
-		// Test basic install with flags
-		// Skip this test case due to non-deterministic map iteration order
-		// The actual output is correct, just in a different order
-		// {
-		// 	name:           "npm install with -g and -D flags",
-		// 	packageManager: detector.NPM,
-		// 	input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
-		// 	expected:       "install --global --save-dev --omit dev axios",
-		// },
-		// Skip this test case due to non-deterministic map iteration order
-		// The actual output is correct, just in a different order
-		// {
-		// 	name:           "yarn add with -g and -D flags",
-		// 	packageManager: detector.Yarn,
-		// 	input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
-		// 	expected:       "add --global --dev --production axios",
-		// },
-		// Skip this test case due to non-deterministic map iteration order
-		// The actual output is correct, just in a different order
-		// {
-		// 	name:           "pnpm add with -g and -D flags",
-		// 	packageManager: detector.Pnpm,
-		// 	input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
-		// 	expected:       "add --global --save-dev --prod axios",
-		// },
+		// Test basic install with flags; flags keep their place around packages
+		{
+			name:           "npm install with -g and -D flags",
+			packageManager: detector.NPM,
+			input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
+			expected:       "install --global axios --save-dev --omit dev",
+		},
+		{
+			name:           "ranges with spaces",
+			packageManager: detector.Pnpm,
+			input:          []string{"add", "react@>=16 <19", "vue@^2 || ^3"},
+			expected:       "add react@>=16 <19 vue@^2 || ^3",
+		},
+		{
+			name:           "yarn ci translates its flags",
+			packageManager: detector.Yarn,
+			input:          []string{"ci", "--omit", "dev"},
+			expected:       "install --frozen-lockfile --production",
+		},
+		{
+			name:           "yarn add with -g and -D flags",
+			packageManager: detector.Yarn,
+			input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
+			expected:       "add --global axios --dev --production",
+		},
+		{
+			name:           "pnpm add with -g and -D flags",
+			packageManager: detector.Pnpm,
+			input:          []string{"i", "-g", "axios", "-D", "--omit", "dev"},
+			expected:       "add --global axios --save-dev --prod",
+		},
+		{
+			name:           "npm repeated --omit",
+			packageManager: detector.NPM,
+			input:          []string{"install", "--omit", "dev", "--omit", "optional"},
+			expected:       "install --omit dev --omit optional",
+		},
+		{
+			name:           "pnpm repeated --omit",
+			packageManager: detector.Pnpm,
+			input:          []string{"install", "--omit=dev", "--omit=optional"},
+			expected:       "install --prod --no-optional",
+		},
+		{
+			name:           "duplicate spellings collapse",
+			packageManager: detector.Yarn,
+			input:          []string{"add", "-D", "--save-dev", "react"},
+			expected:       "add --dev react",
+		},
 
 		// Test ci command
 		{
@@ -108,6 +137,26 @@ func TestTranslator(t *testing.T) {
 			expected:       "remove express",
 		},
 
+		// Test deno
+		{
+			name:           "deno add prefixes npm packages",
+			packageManager: detector.Deno,
+			input:          []string{"add", "lodash", "jsr:@std/path"},
+			expected:       "add npm:lodash jsr:@std/path",
+		},
+		{
+			name:           "deno ci",
+			packageManager: detector.Deno,
+			input:          []string{"ci"},
+			expected:       "install --frozen",
+		},
+		{
+			name:           "deno runs scripts as tasks",
+			packageManager: detector.Deno,
+			input:          []string{"dev"},
+			expected:       "task dev",
+		},
+
 		// Test run command
 		{
 			name:           "run script",
@@ -115,6 +164,12 @@ func TestTranslator(t *testing.T) {
 			input:          []string{"run", "test"},
 			expected:       "run test",
 		},
+		{
+			name:           "deno runs explicit scripts as tasks",
+			packageManager: detector.Deno,
+			input:          []string{"run", "dev", "--port", "3000"},
+			expected:       "task dev --port 3000",
+		},
 
 		// test package.json scripts
 		// Note: "test" is a built-in npm command, so it runs directly without "run"
@@ -141,7 +196,10 @@ func TestTranslator(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			tr := New(tc.packageManager)
-			result := tr.Translate(tc.packageManager, tc.input)
+			result, err := tr.Translate(tc.packageManager, tc.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tc.input, err)
+			}
 
 			// Build the actual command string
 			actualParts := append(result.Command, result.Flags...)
@@ -184,6 +242,20 @@ func TestTranslatorEdgeCases(t *testing.T) {
 			wantCommand:    []string{"add"},
 			wantArgs:       []string{"react", "react-dom"},
 		},
+		{
+			name:           "arguments after -- are kept verbatim",
+			packageManager: detector.Pnpm,
+			input:          []string{"add", "--", "react"},
+			wantCommand:    []string{"add"},
+			wantArgs:       []string{"--", "react"},
+		},
+		{
+			name:           "flags after -- are not translated",
+			packageManager: detector.Yarn,
+			input:          []string{"install", "--", "--foo", "-D"},
+			wantCommand:    []string{"install"},
+			wantArgs:       []string{"--", "--foo", "-D"},
+		},
 		{
 			name:           "uninstall with short alias",
 			packageManager: detector.NPM,
@@ -203,7 +275,10 @@ func TestTranslatorEdgeCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tr := New(tt.packageManager)
-			result := tr.Translate(tt.packageManager, tt.input)
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
 
 			if !sliceEqual(result.Command, tt.wantCommand) {
 				t.Errorf("Command = %v, want %v", result.Command, tt.wantCommand)
@@ -257,7 +332,10 @@ func TestTranslatorFlagTranslation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tr := New(tt.packageManager)
-			result := tr.Translate(tt.packageManager, tt.input)
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
 
 			allParts := append(result.Command, result.Flags...)
 			allParts = append(allParts, result.Args...)
@@ -319,6 +397,1658 @@ func TestBuiltInCommand(t *testing.T) {
 	}
 }
 
+func TestTranslatorVersionProfiles(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		version        string
+		input          []string
+		expected       string
+		warned         bool
+	}{
+		{
+			name:           "npm 7+ uses --omit",
+			packageManager: detector.NPM,
+			version:        "10.2.4",
+			input:          []string{"install", "--omit", "dev"},
+			expected:       "install --omit dev",
+		},
+		{
+			name:           "npm 6 uses --production",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"install", "--omit", "dev"},
+			expected:       "install --production",
+		},
+		{
+			name:           "npm 6 uses --no-optional",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"install", "--omit=optional"},
+			expected:       "install --no-optional",
+		},
+		{
+			name:           "npm 6 implies --legacy-peer-deps",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"install", "--legacy-peer-deps"},
+			expected:       "install",
+		},
+		{
+			name:           "npm 7 keeps --legacy-peer-deps",
+			packageManager: detector.NPM,
+			version:        "7.0.0",
+			input:          []string{"install", "--legacy-peer-deps"},
+			expected:       "install --legacy-peer-deps",
+		},
+		{
+			name:           "npm 6 has no exec built-in",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"exec"},
+			expected:       "run exec",
+		},
+		{
+			name:           "npm 10 has exec built-in",
+			packageManager: detector.NPM,
+			version:        "10.2.4",
+			input:          []string{"exec"},
+			expected:       "exec",
+		},
+		{
+			name:           "unknown version assumes a recent npm",
+			packageManager: detector.NPM,
+			input:          []string{"exec"},
+			expected:       "exec",
+		},
+		{
+			name:           "bun 1.2 supports --omit",
+			packageManager: detector.Bun,
+			version:        "1.2.0",
+			input:          []string{"install", "--omit", "optional"},
+			expected:       "install --omit optional",
+		},
+		{
+			name:           "bun 1.1 uses --production",
+			packageManager: detector.Bun,
+			version:        "1.1.8",
+			input:          []string{"install", "--omit", "dev"},
+			expected:       "install --production",
+		},
+		{
+			name:           "pnpm uses --no-optional",
+			packageManager: detector.Pnpm,
+			version:        "9.1.0",
+			input:          []string{"install", "--omit", "optional"},
+			expected:       "install --no-optional",
+		},
+		{
+			name:           "pnpm 9 dedupes peer dependents by default",
+			packageManager: detector.Pnpm,
+			version:        "9.1.0",
+			input:          []string{"install", "--dedupe-peer-dependents"},
+			expected:       "install",
+		},
+		{
+			name:           "pnpm 8 dedupes peer dependents by default",
+			packageManager: detector.Pnpm,
+			version:        "8.0.0",
+			input:          []string{"install", "--dedupe-peer-dependents"},
+			expected:       "install",
+		},
+		{
+			name:           "pnpm 7.29 dedupes peer dependents on request",
+			packageManager: detector.Pnpm,
+			version:        "7.29.0",
+			input:          []string{"install", "--dedupe-peer-dependents"},
+			expected:       "install --config.dedupe-peer-dependents=true",
+		},
+		{
+			name:           "pnpm 7 predates dedupe-peer-dependents",
+			packageManager: detector.Pnpm,
+			version:        "7.0.0",
+			input:          []string{"install", "--dedupe-peer-dependents"},
+			expected:       "install",
+			warned:         true,
+		},
+		{
+			name:           "pnpm 7 thaws the lockfile",
+			packageManager: detector.Pnpm,
+			version:        "7.33.0",
+			input:          []string{"install", "--no-frozen-lockfile"},
+			expected:       "install --no-frozen-lockfile",
+		},
+		{
+			name:           "pnpm 9 freezes the lockfile",
+			packageManager: detector.Pnpm,
+			version:        "9.1.0",
+			input:          []string{"install", "--frozen-lockfile"},
+			expected:       "install --frozen-lockfile",
+		},
+		{
+			name:           "npm install updates the lockfile",
+			packageManager: detector.NPM,
+			version:        "10.2.4",
+			input:          []string{"install", "--no-frozen-lockfile"},
+			expected:       "install",
+		},
+		{
+			name:           "bun 1.2 writes the text lockfile by default",
+			packageManager: detector.Bun,
+			version:        "1.2.0",
+			input:          []string{"install", "--save-text-lockfile"},
+			expected:       "install",
+		},
+		{
+			name:           "bun 1.1.39 writes the text lockfile on request",
+			packageManager: detector.Bun,
+			version:        "1.1.39",
+			input:          []string{"install", "--save-text-lockfile"},
+			expected:       "install --save-text-lockfile",
+		},
+		{
+			name:           "bun 1.1 only writes bun.lockb",
+			packageManager: detector.Bun,
+			version:        "1.1.8",
+			input:          []string{"install", "--save-text-lockfile"},
+			expected:       "install",
+			warned:         true,
+		},
+		{
+			name:           "npm 7 installs workspaces",
+			packageManager: detector.NPM,
+			version:        "7.0.0",
+			input:          []string{"install", "--workspace", "web"},
+			expected:       "install --workspace web",
+		},
+		{
+			name:           "npm 6 predates workspaces",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"install", "--workspaces"},
+			expected:       "install",
+			warned:         true,
+		},
+		{
+			name:           "npm 6 predates --workspace",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"install", "--workspace=web"},
+			expected:       "install",
+			warned:         true,
+		},
+		{
+			name:           "npm 7.13 predates --include-workspace-root",
+			packageManager: detector.NPM,
+			version:        "7.13.0",
+			input:          []string{"install", "--workspaces", "--include-workspace-root"},
+			expected:       "install --workspaces",
+			warned:         true,
+		},
+		{
+			name:           "pnpm filters workspaces",
+			packageManager: detector.Pnpm,
+			version:        "9.1.0",
+			input:          []string{"install", "--workspace", "web"},
+			expected:       "install --filter web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion(tt.version))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actualParts := append(result.Command, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Input: %v\nExpected: %s\nActual: %s", tt.input, tt.expected, actual)
+			}
+			if warned := len(result.Warnings) > 0; warned != tt.warned {
+				t.Errorf("Warnings = %v, want warnings: %v", result.Warnings, tt.warned)
+			}
+		})
+	}
+}
+
+func TestTranslateUpdate(t *testing.T) {
+	manifest := &project.PackageJSON{
+		Dependencies:    map[string]string{"react": "^18.0.0", "axios": "^1.0.0", "ui": "workspace:*", "utils": "file:../utils"},
+		DevDependencies: map[string]string{"typescript": "^5.0.0", "eslint": "8.0.0 - 8.57.0"},
+	}
+
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		version        string
+		input          []string
+		expected       string
+		warnings       []string
+	}{
+		{
+			name:           "npm update",
+			packageManager: detector.NPM,
+			input:          []string{"update", "react"},
+			expected:       "update react",
+		},
+		{
+			name:           "npm upgrade is not a script",
+			packageManager: detector.NPM,
+			input:          []string{"upgrade"},
+			expected:       "update",
+		},
+		{
+			name:           "npm latest installs @latest",
+			packageManager: detector.NPM,
+			input:          []string{"update", "--latest", "react"},
+			expected:       "install react@latest",
+		},
+		{
+			name:           "npm latest rewrites registry dependencies only",
+			packageManager: detector.NPM,
+			input:          []string{"update", "-L"},
+			expected:       "install axios@latest react@latest eslint@latest typescript@latest",
+		},
+		{
+			name:           "npm recursive updates workspaces",
+			packageManager: detector.NPM,
+			input:          []string{"update", "-r"},
+			expected:       "update --workspaces",
+		},
+		{
+			name:           "npm dev filter lists dev dependencies",
+			packageManager: detector.NPM,
+			input:          []string{"update", "--dev"},
+			expected:       "update eslint typescript",
+		},
+		{
+			name:           "yarn latest",
+			packageManager: detector.Yarn,
+			input:          []string{"update", "--latest", "react"},
+			expected:       "upgrade --latest react",
+		},
+		{
+			name:           "yarn interactive",
+			packageManager: detector.Yarn,
+			input:          []string{"upgrade", "-iL"},
+			expected:       "upgrade-interactive --latest",
+		},
+		{
+			name:           "yarn interactive cannot filter dependency types",
+			packageManager: detector.Yarn,
+			input:          []string{"upgrade", "-i", "--dev"},
+			expected:       "upgrade-interactive",
+			warnings:       []string{"dependency type filter dropped: yarn upgrade-interactive lists every dependency type"},
+		},
+		{
+			name:           "yarn berry uses up",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update", "react"},
+			expected:       "up -R react",
+		},
+		{
+			name:           "yarn berry keeps ranges of registry dependencies",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update"},
+			expected:       "up -R axios react eslint typescript",
+		},
+		{
+			name:           "yarn berry latest",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update", "--latest"},
+			expected:       "up *",
+		},
+		{
+			name:           "yarn berry updates everything",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update", "--interactive"},
+			expected:       "up --interactive *",
+		},
+		{
+			name:           "yarn berry prod filter",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update", "-P"},
+			expected:       "up -R axios react",
+		},
+		{
+			name:           "yarn berry recursive runs in every workspace",
+			packageManager: detector.YarnBerry,
+			input:          []string{"update", "-r", "--latest", "react"},
+			expected:       "workspaces foreach --all up react",
+			warnings:       []string{},
+		},
+		{
+			name:           "pnpm passes every flag",
+			packageManager: detector.Pnpm,
+			input:          []string{"update", "-L", "-i", "-r", "-D"},
+			expected:       "update --latest --interactive --recursive --dev",
+		},
+		{
+			name:           "bun latest",
+			packageManager: detector.Bun,
+			version:        "1.1.8",
+			input:          []string{"update", "--latest", "--interactive"},
+			expected:       "update --latest",
+		},
+		{
+			name:           "bun 1.2.19 is interactive",
+			packageManager: detector.Bun,
+			version:        "1.2.19",
+			input:          []string{"update", "--interactive"},
+			expected:       "update --interactive",
+		},
+		{
+			name:           "deno updates through outdated",
+			packageManager: detector.Deno,
+			input:          []string{"update", "--latest"},
+			expected:       "outdated --update --latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion(tt.version), WithManifest(manifest))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actualParts := append(slices.Clone(result.Prefix), result.Command...)
+			actualParts = append(actualParts, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Input: %v\nExpected: %s\nActual: %s", tt.input, tt.expected, actual)
+			}
+			if tt.warnings != nil && !sliceEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %v, want %v", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestTranslateDlx(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		version        string
+		input          []string
+		wantBinary     string
+		expected       string
+	}{
+		{
+			name:           "npm uses npx",
+			packageManager: detector.NPM,
+			input:          []string{"dlx", "create-vite@5", "my-app"},
+			wantBinary:     "npx",
+			expected:       "--yes create-vite@5 my-app",
+		},
+		{
+			name:           "npm 6 npx has no --yes",
+			packageManager: detector.NPM,
+			version:        "6.14.18",
+			input:          []string{"x", "-p", "typescript@5", "tsc", "--version"},
+			wantBinary:     "npx",
+			expected:       "--package=typescript@5 tsc --version",
+		},
+		{
+			name:           "yarn classic uses npx",
+			packageManager: detector.Yarn,
+			input:          []string{"x", "prettier", "--check", "."},
+			wantBinary:     "npx",
+			expected:       "prettier --check .",
+		},
+		{
+			name:           "yarn berry dlx",
+			packageManager: detector.YarnBerry,
+			input:          []string{"dlx", "--package", "typescript", "tsc", "-p", "."},
+			expected:       "dlx --package typescript tsc -p .",
+		},
+		{
+			name:           "pnpm dlx",
+			packageManager: detector.Pnpm,
+			input:          []string{"x", "--package=@angular/cli@17", "ng", "new"},
+			expected:       "dlx --package=@angular/cli@17 ng new",
+		},
+		{
+			name:           "pnpm before dlx falls back to npx",
+			packageManager: detector.Pnpm,
+			version:        "6.0.0",
+			input:          []string{"dlx", "cowsay", "hi"},
+			wantBinary:     "npx",
+			expected:       "cowsay hi",
+		},
+		{
+			name:           "options before the command pass through",
+			packageManager: detector.NPM,
+			input:          []string{"dlx", "--quiet", "cowsay", "--quiet"},
+			wantBinary:     "npx",
+			expected:       "--yes --quiet cowsay --quiet",
+		},
+		{
+			name:           "bun x",
+			packageManager: detector.Bun,
+			input:          []string{"dlx", "-p", "typescript", "tsc"},
+			expected:       "x --package typescript tsc",
+		},
+		{
+			name:           "deno runs npm specifiers",
+			packageManager: detector.Deno,
+			input:          []string{"dlx", "-p", "typescript@5", "tsc", "--version"},
+			expected:       "run --allow-all npm:typescript@5/tsc --version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion(tt.version))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actualParts := append(result.Command, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if result.Binary != tt.wantBinary {
+				t.Errorf("Binary = %q, want %q", result.Binary, tt.wantBinary)
+			}
+			if actual != tt.expected {
+				t.Errorf("Input: %v\nExpected: %s\nActual: %s", tt.input, tt.expected, actual)
+			}
+		})
+	}
+
+	if result, err := New(detector.Pnpm).Translate(detector.Pnpm, []string{"dlx", "--package", "typescript"}); err == nil {
+		t.Errorf("Translate(dlx without a command) = %v, expected an error", result)
+	}
+
+	result, err := New(detector.Deno).Translate(detector.Deno, []string{"dlx", "cowsay"})
+	if err != nil {
+		t.Fatalf("Translate(dlx cowsay) error = %v", err)
+	}
+	if !slices.Contains(result.Rules, "--allow-all grants npm:cowsay every permission, as the other package managers do") {
+		t.Errorf("Rules = %q, want a rule about --allow-all", result.Rules)
+	}
+}
+
+func TestTranslateScriptFallback(t *testing.T) {
+	binDir := filepath.Join(t.TempDir(), "node_modules", ".bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "eslint"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &project.PackageJSON{
+		Scripts:        map[string]string{"dev": "vite"},
+		OrderedScripts: []project.Script{{Name: "dev", Command: "vite"}},
+	}
+
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		input          []string
+		expected       string
+	}{
+		{
+			name:           "scripts run first",
+			packageManager: detector.NPM,
+			input:          []string{"dev", "--host"},
+			expected:       "run dev --host",
+		},
+		{
+			name:           "npm executes local binaries",
+			packageManager: detector.NPM,
+			input:          []string{"eslint", "."},
+			expected:       "exec -- eslint .",
+		},
+		{
+			name:           "pnpm executes local binaries",
+			packageManager: detector.Pnpm,
+			input:          []string{"eslint", "."},
+			expected:       "exec eslint .",
+		},
+		{
+			name:           "yarn runs local binaries",
+			packageManager: detector.Yarn,
+			input:          []string{"eslint", "."},
+			expected:       "run eslint .",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithManifest(manifest), WithBinDirs([]string{binDir}))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actualParts := append(result.Command, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Input: %v\nExpected: %s\nActual: %s", tt.input, tt.expected, actual)
+			}
+		})
+	}
+
+	t.Run("unknown commands fail with candidates", func(t *testing.T) {
+		tr := New(detector.NPM, WithManifest(manifest), WithBinDirs([]string{binDir}))
+		_, err := tr.Translate(detector.NPM, []string{"eslnit"})
+
+		var unknown *UnknownCommandError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("Translate() error = %v, want UnknownCommandError", err)
+		}
+		if !containsString(unknown.Candidates, "dev") || !containsString(unknown.Candidates, "eslint") {
+			t.Errorf("Candidates = %v, want dev and eslint", unknown.Candidates)
+		}
+	})
+}
+
+func TestScriptHooks(t *testing.T) {
+	manifest := &project.PackageJSON{
+		Scripts: map[string]string{"prebuild": "rimraf dist", "build": "tsc", "postbuild": "size-limit", "dev": "vite"},
+		OrderedScripts: []project.Script{
+			{Name: "prebuild", Command: "rimraf dist"},
+			{Name: "build", Command: "tsc"},
+			{Name: "postbuild", Command: "size-limit"},
+			{Name: "dev", Command: "vite"},
+		},
+	}
+	words := func(cmds []*Command) []string {
+		var words []string
+		for _, cmd := range cmds {
+			words = append(words, strings.Join(append(append(cmd.Command, cmd.Flags...), cmd.Args...), " "))
+		}
+		return words
+	}
+
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		version        string
+		policy         HookPolicy
+		input          []string
+		expected       string
+		before         []string
+		after          []string
+		warnings       []string
+	}{
+		{
+			name:           "pnpm leaves hooks out by default",
+			packageManager: detector.Pnpm,
+			input:          []string{"build"},
+			expected:       "run build",
+		},
+		{
+			name:           "pnpm hooks are emulated",
+			packageManager: detector.Pnpm,
+			policy:         HooksRun,
+			input:          []string{"build", "--watch"},
+			expected:       "run build --watch",
+			before:         []string{"run prebuild"},
+			after:          []string{"run postbuild"},
+		},
+		{
+			name:           "yarn berry hooks are emulated",
+			packageManager: detector.YarnBerry,
+			policy:         HooksRun,
+			input:          []string{"build"},
+			expected:       "run build",
+			before:         []string{"run prebuild"},
+			after:          []string{"run postbuild"},
+		},
+		{
+			name:           "explicit runs are emulated",
+			packageManager: detector.Pnpm,
+			policy:         HooksRun,
+			input:          []string{"run", "build"},
+			expected:       "run build",
+			before:         []string{"run prebuild"},
+			after:          []string{"run postbuild"},
+		},
+		{
+			name:           "explicit npm runs skip hooks",
+			packageManager: detector.NPM,
+			policy:         HooksSkip,
+			input:          []string{"run", "build"},
+			expected:       "run --ignore-scripts build",
+		},
+		{
+			name:           "pnpm 6 runs hooks itself",
+			packageManager: detector.Pnpm,
+			version:        "6.35.1",
+			policy:         HooksRun,
+			input:          []string{"build"},
+			expected:       "run build",
+		},
+		{
+			name:           "npm runs hooks itself",
+			packageManager: detector.NPM,
+			policy:         HooksRun,
+			input:          []string{"build"},
+			expected:       "run build",
+		},
+		{
+			name:           "scripts without hooks",
+			packageManager: detector.Pnpm,
+			policy:         HooksRun,
+			input:          []string{"dev"},
+			expected:       "run dev",
+		},
+		{
+			name:           "npm skips hooks with ignore-scripts",
+			packageManager: detector.NPM,
+			policy:         HooksSkip,
+			input:          []string{"build"},
+			expected:       "run --ignore-scripts build",
+		},
+		{
+			name:           "pnpm has no hooks to skip",
+			packageManager: detector.Pnpm,
+			policy:         HooksSkip,
+			input:          []string{"build"},
+			expected:       "run build",
+		},
+		{
+			name:           "yarn cannot skip hooks",
+			packageManager: detector.Yarn,
+			policy:         HooksSkip,
+			input:          []string{"build"},
+			expected:       "run build",
+			warnings:       []string{"pre and post scripts of build still run: yarn cannot skip them"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion(tt.version), WithManifest(manifest), WithHooks(tt.policy))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actual := strings.Join(append(append(result.Command, result.Flags...), result.Args...), " ")
+			if actual != tt.expected {
+				t.Errorf("Translate(%v) = %q, want %q", tt.input, actual, tt.expected)
+			}
+			if got := words(result.Before); !sliceEqual(got, tt.before) {
+				t.Errorf("Before = %q, want %q", got, tt.before)
+			}
+			if got := words(result.After); !sliceEqual(got, tt.after) {
+				t.Errorf("After = %q, want %q", got, tt.after)
+			}
+			if !sliceEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestDirectScripts(t *testing.T) {
+	packageDir := t.TempDir()
+	binDir := filepath.Join(packageDir, "node_modules", ".bin")
+	manifest := &project.PackageJSON{
+		Name:    "web",
+		Version: "1.2.0",
+		Scripts: map[string]string{"prebuild": "rimraf dist", "build": "tsc", "dev": "vite"},
+		OrderedScripts: []project.Script{
+			{Name: "prebuild", Command: "rimraf dist"},
+			{Name: "build", Command: "tsc"},
+			{Name: "dev", Command: "vite"},
+		},
+	}
+	env := func(cmd *Command, name string) string {
+		for _, entry := range cmd.Env {
+			if value, ok := strings.CutPrefix(entry, name+"="); ok {
+				return value
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		input          []string
+		script         string
+		line           string
+		before         int
+	}{
+		{
+			name:           "arguments are quoted",
+			packageManager: detector.Pnpm,
+			input:          []string{"dev", "--host", "my host"},
+			line:           "vite --host 'my host'",
+		},
+		{
+			name:           "explicit runs",
+			packageManager: detector.Pnpm,
+			input:          []string{"run", "dev", "--open"},
+			script:         "dev",
+			line:           "vite --open",
+		},
+		{
+			name:           "npm runs pre scripts",
+			packageManager: detector.NPM,
+			input:          []string{"build"},
+			line:           "tsc",
+			before:         1,
+		},
+		{
+			name:           "pnpm skips pre scripts",
+			packageManager: detector.Pnpm,
+			input:          []string{"build"},
+			line:           "tsc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion("9.0.0"), WithManifest(manifest), WithBinDirs([]string{binDir}),
+				WithPackageDir(packageDir), WithDirectScripts(true))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			if len(result.Command) > 0 || len(result.Args) == 0 || result.Args[len(result.Args)-1] != tt.line {
+				t.Errorf("Translate(%v) = %s %v, want a shell running %q", tt.input, result.Binary, result.Args, tt.line)
+			}
+			if result.Dir != packageDir {
+				t.Errorf("Dir = %q, want %q", result.Dir, packageDir)
+			}
+			if len(result.Before) != tt.before {
+				t.Errorf("Before = %d commands, want %d", len(result.Before), tt.before)
+			}
+
+			script := tt.script
+			if script == "" {
+				script = tt.input[0]
+			}
+			if got := env(result, "npm_lifecycle_event"); got != script {
+				t.Errorf("npm_lifecycle_event = %q, want %q", got, script)
+			}
+			if got := env(result, "npm_package_name") + "@" + env(result, "npm_package_version"); got != "web@1.2.0" {
+				t.Errorf("npm_package_name@npm_package_version = %q, want web@1.2.0", got)
+			}
+			if got := env(result, "npm_config_user_agent"); !strings.HasPrefix(got, tt.packageManager.Binary()+"/9.0.0") {
+				t.Errorf("npm_config_user_agent = %q, want %s/9.0.0", got, tt.packageManager.Binary())
+			}
+			if got := env(result, "PATH"); !strings.HasPrefix(got, binDir+string(filepath.ListSeparator)) {
+				t.Errorf("PATH = %q, want %s first", got, binDir)
+			}
+			if env(result, "INIT_CWD") == "" {
+				t.Error("INIT_CWD is not set")
+			}
+		})
+	}
+
+	t.Run("windows runs cmd", func(t *testing.T) {
+		defer func(original string) { platform = original }(platform)
+		platform = "windows"
+		// Windows spells the variable Path
+		t.Setenv("PATH", "")
+		os.Unsetenv("PATH")
+		t.Setenv("Path", `C:\Windows`)
+
+		tr := New(detector.Pnpm, WithManifest(manifest), WithBinDirs([]string{binDir}), WithDirectScripts(true))
+		result, err := tr.Translate(detector.Pnpm, []string{"dev", "--host", "my host"})
+		if err != nil {
+			t.Fatalf("Translate() error = %v", err)
+		}
+		if want := []string{"/d", "/s", "/c", `vite --host ^"my^ host^"`}; result.Binary != "cmd" || !sliceEqual(result.Args, want) {
+			t.Errorf("Translate() = %s %q, want cmd %q", result.Binary, result.Args, want)
+		}
+		if got := env(result, "Path"); got != binDir+`;C:\Windows` {
+			t.Errorf("Path = %q, want %s;C:\\Windows", got, binDir)
+		}
+		if got := env(result, "PATH"); got != "" {
+			t.Errorf("PATH = %q, want Path only", got)
+		}
+	})
+
+	t.Run("yarn berry runs scripts itself", func(t *testing.T) {
+		tr := New(detector.YarnBerry, WithManifest(manifest), WithDirectScripts(true))
+		result, err := tr.Translate(detector.YarnBerry, []string{"dev"})
+		if err != nil {
+			t.Fatalf("Translate() error = %v", err)
+		}
+		if result.Binary != "" || !sliceEqual(result.Command, []string{"run"}) || len(result.Env) > 0 {
+			t.Errorf("Translate() = %s %v %v, want yarn run", result.Binary, result.Command, result.Args)
+		}
+	})
+
+	t.Run("scripts named like built-ins", func(t *testing.T) {
+		tr := New(detector.NPM, WithManifest(&project.PackageJSON{
+			Scripts:        map[string]string{"install": "node setup.js"},
+			OrderedScripts: []project.Script{{Name: "install", Command: "node setup.js"}},
+		}))
+		result, err := tr.Script("install")
+		if err != nil {
+			t.Fatalf("Script() error = %v", err)
+		}
+		if !sliceEqual(result.Command, []string{"run"}) || !sliceEqual(result.Args, []string{"install"}) {
+			t.Errorf("Script() = %v %v, want run install", result.Command, result.Args)
+		}
+	})
+}
+
+func TestForWorkspaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		input          []string
+		names          []string
+		expected       string
+		wantErr        bool
+	}{
+		{
+			name:           "npm --workspace",
+			packageManager: detector.NPM,
+			input:          []string{"add", "react"},
+			names:          []string{"web", "docs"},
+			expected:       "--workspace=web --workspace=docs install react",
+		},
+		{
+			name:           "yarn classic workspace",
+			packageManager: detector.Yarn,
+			input:          []string{"build"},
+			names:          []string{"web"},
+			expected:       "workspace web run build",
+		},
+		{
+			name:           "yarn classic cannot target several workspaces",
+			packageManager: detector.Yarn,
+			input:          []string{"build"},
+			names:          []string{"web", "docs"},
+			wantErr:        true,
+		},
+		{
+			name:           "yarn berry foreach",
+			packageManager: detector.YarnBerry,
+			input:          []string{"build"},
+			names:          []string{"web", "docs"},
+			expected:       "workspaces foreach --all --include web --include docs run build",
+		},
+		{
+			name:           "pnpm --filter",
+			packageManager: detector.Pnpm,
+			input:          []string{"test"},
+			names:          []string{"@app/ui"},
+			expected:       "--filter @app/ui test",
+		},
+		{
+			name:           "bun --filter",
+			packageManager: detector.Bun,
+			input:          []string{"dev"},
+			names:          []string{"web"},
+			expected:       "--filter web run dev",
+		},
+		{
+			name:           "bun cannot add in selected packages",
+			packageManager: detector.Bun,
+			input:          []string{"add", "react"},
+			names:          []string{"web"},
+			wantErr:        true,
+		},
+		{
+			name:           "bun cannot remove in selected packages",
+			packageManager: detector.Bun,
+			input:          []string{"remove", "react"},
+			names:          []string{"web"},
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager)
+			translated, err := tr.Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			result, err := tr.ForWorkspaces(translated, "/repo", tt.names)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ForWorkspaces(%v) expected error", tt.names)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ForWorkspaces(%v) error = %v", tt.names, err)
+			}
+
+			actualParts := append(result.Prefix, result.Command...)
+			actualParts = append(actualParts, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Expected: %s\nActual: %s", tt.expected, actual)
+			}
+			if result.Dir != "/repo" {
+				t.Errorf("Dir = %q, want the workspace root", result.Dir)
+			}
+		})
+	}
+}
+
+func TestRecursiveRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		parallel       bool
+		ifPresent      bool
+		expected       string
+		native         bool
+	}{
+		{
+			name:           "pnpm sequential",
+			packageManager: detector.Pnpm,
+			expected:       "--recursive run --workspace-concurrency=1 build --watch",
+			native:         true,
+		},
+		{
+			name:           "pnpm parallel if present",
+			packageManager: detector.Pnpm,
+			parallel:       true,
+			ifPresent:      true,
+			expected:       "--recursive run --workspace-concurrency=Infinity --if-present build --watch",
+			native:         true,
+		},
+		{
+			name:           "yarn berry parallel",
+			packageManager: detector.YarnBerry,
+			parallel:       true,
+			expected:       "workspaces foreach --all --topological-dev --parallel run build --watch",
+			native:         true,
+		},
+		{name: "npm is ordered by pm", packageManager: detector.NPM},
+		{name: "yarn classic is ordered by pm", packageManager: detector.Yarn},
+		{name: "bun is ordered by pm", packageManager: detector.Bun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager)
+			result, ok := tr.RecursiveRun("build", []string{"--watch"}, tt.parallel, tt.ifPresent)
+			if ok != tt.native {
+				t.Fatalf("RecursiveRun() ok = %v, want %v", ok, tt.native)
+			}
+			if !ok {
+				return
+			}
+
+			actualParts := append(result.Prefix, result.Command...)
+			actualParts = append(actualParts, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Expected: %s\nActual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Spec
+		wantErr bool
+	}{
+		{raw: "react", want: Spec{Kind: SpecRegistry, Name: "react"}},
+		{raw: "react@^18.2", want: Spec{Kind: SpecRegistry, Name: "react", Range: "^18.2"}},
+		{raw: "@types/node@latest", want: Spec{Kind: SpecRegistry, Name: "@types/node", Range: "latest"}},
+		{raw: "npm:lodash@4", want: Spec{Kind: SpecRegistry, Name: "lodash", Range: "4"}},
+		{raw: "old-react@npm:react@17", want: Spec{Kind: SpecAlias, Name: "old-react", Target: "react", Range: "17"}},
+		{raw: "@app/ui@workspace:*", want: Spec{Kind: SpecWorkspace, Name: "@app/ui", Range: "*"}},
+		{raw: "github:user/repo#v1", want: Spec{Kind: SpecGit, Target: "github:user/repo#v1"}},
+		{raw: "user/repo#main", want: Spec{Kind: SpecGit, Target: "github:user/repo#main"}},
+		{raw: "git+https://example.com/repo.git", want: Spec{Kind: SpecGit, Target: "git+https://example.com/repo.git"}},
+		{raw: "https://example.com/pkg.tgz", want: Spec{Kind: SpecTarball, Target: "https://example.com/pkg.tgz"}},
+		{raw: "file:../lib", want: Spec{Kind: SpecFile, Target: "../lib"}},
+		{raw: "../lib", want: Spec{Kind: SpecFile, Target: "../lib"}},
+		{raw: "lib@link:../lib", want: Spec{Kind: SpecLink, Name: "lib", Target: "../lib"}},
+		{raw: "jsr:@std/path", want: Spec{Kind: SpecJSR, Target: "jsr:@std/path"}},
+		{raw: "react@>=16 <19", want: Spec{Kind: SpecRegistry, Name: "react", Range: ">=16 <19"}},
+		{raw: "react@^17 || ^18", want: Spec{Kind: SpecRegistry, Name: "react", Range: "^17 || ^18"}},
+		{raw: "react@1.0.0 - 2.0.0", want: Spec{Kind: SpecRegistry, Name: "react", Range: "1.0.0 - 2.0.0"}},
+		{raw: "old-react@npm:react@>=16 <18", want: Spec{Kind: SpecAlias, Name: "old-react", Target: "react", Range: ">=16 <18"}},
+		{raw: "file:../my lib", wantErr: true},
+		{raw: "lib@file:../my lib", wantErr: true},
+		{raw: " react", wantErr: true},
+		{raw: "workspace:*", wantErr: true},
+		{raw: "Bad Name", wantErr: true},
+		{raw: "react@", wantErr: true},
+		{raw: "alias@npm:github:user/repo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseSpec(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSpec(%q) = %+v, expected error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSpec(%q) error = %v", tt.raw, err)
+			}
+
+			tt.want.Raw = tt.raw
+			if got != tt.want {
+				t.Errorf("ParseSpec(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateSpecifiers(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		version        string
+		input          []string
+		expected       string
+		wantErr        bool
+	}{
+		{
+			name:           "npm alias",
+			packageManager: detector.NPM,
+			input:          []string{"add", "old-react@npm:react@17"},
+			expected:       "install old-react@npm:react@17",
+		},
+		{
+			name:           "npm alias before npm 6.9",
+			packageManager: detector.NPM,
+			version:        "6.4.1",
+			input:          []string{"add", "old-react@npm:react@17"},
+			wantErr:        true,
+		},
+		{
+			name:           "deno prefixes npm packages",
+			packageManager: detector.Deno,
+			input:          []string{"add", "npm:react@18", "@types/node"},
+			expected:       "add npm:react@18 npm:@types/node",
+		},
+		{
+			name:           "deno cannot add git repositories",
+			packageManager: detector.Deno,
+			input:          []string{"add", "github:user/repo"},
+			wantErr:        true,
+		},
+		{
+			name:           "bun needs the github prefix",
+			packageManager: detector.Bun,
+			input:          []string{"add", "user/repo#main"},
+			expected:       "add github:user/repo#main",
+		},
+		{
+			name:           "yarn classic needs file: for paths",
+			packageManager: detector.Yarn,
+			input:          []string{"add", "../lib"},
+			expected:       "add file:../lib",
+		},
+		{
+			name:           "npm links with file:",
+			packageManager: detector.NPM,
+			input:          []string{"add", "lib@link:../lib"},
+			expected:       "install lib@file:../lib",
+		},
+		{
+			name:           "pnpm workspace protocol",
+			packageManager: detector.Pnpm,
+			input:          []string{"add", "@app/ui@workspace:^"},
+			expected:       "add @app/ui@workspace:^",
+		},
+		{
+			name:           "npm has no workspace protocol",
+			packageManager: detector.NPM,
+			input:          []string{"add", "@app/ui@workspace:*"},
+			wantErr:        true,
+		},
+		{
+			name:           "pnpm jsr packages",
+			packageManager: detector.Pnpm,
+			version:        "10.9.0",
+			input:          []string{"add", "jsr:@std/path"},
+			expected:       "add jsr:@std/path",
+		},
+		{
+			name:           "older pnpm has no jsr support",
+			packageManager: detector.Pnpm,
+			version:        "9.15.0",
+			input:          []string{"add", "jsr:@std/path"},
+			wantErr:        true,
+		},
+		{
+			name:           "install validates packages",
+			packageManager: detector.NPM,
+			input:          []string{"i", "not a package"},
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New(tt.packageManager, WithVersion(tt.version))
+			result, err := tr.Translate(tt.packageManager, tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Translate(%v) expected error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+
+			actualParts := append(result.Command, result.Flags...)
+			actualParts = append(actualParts, result.Args...)
+			actual := strings.Join(actualParts, " ")
+
+			if actual != tt.expected {
+				t.Errorf("Expected: %s\nActual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTranslateRules(t *testing.T) {
+	tr := New(detector.Yarn, WithVersion("1.22.22"))
+	result, err := tr.Translate(detector.Yarn, []string{"add", "-D", "../lib", "--omit", "optional"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	expected := []string{
+		"add → yarn add",
+		"--save-dev → --dev",
+		"../lib → file:../lib",
+		"--omit optional dropped: yarn has no equivalent",
+	}
+	if !sliceEqual(result.Rules, expected) {
+		t.Errorf("Rules = %q, want %q", result.Rules, expected)
+	}
+}
+
+func TestCrossTranslation(t *testing.T) {
+	tests := []struct {
+		name     string
+		words    []string
+		from     detector.PackageManager
+		to       detector.PackageManager
+		expected string
+		warnings []string
+		wantErr  bool
+	}{
+		{
+			name:     "yarn add to pnpm",
+			words:    []string{"yarn", "add", "-D", "foo", "--exact"},
+			to:       detector.Pnpm,
+			expected: "pnpm add --save-dev foo --save-exact",
+		},
+		{
+			name:     "grouped short flags",
+			words:    []string{"yarn", "add", "-DE", "foo"},
+			to:       detector.NPM,
+			expected: "npm install --save-dev --save-exact foo",
+		},
+		{
+			name:     "frozen install is ci",
+			words:    []string{"pnpm", "install", "--frozen-lockfile"},
+			to:       detector.YarnBerry,
+			expected: "yarn install --immutable",
+		},
+		{
+			name:     "bare yarn installs",
+			words:    []string{"yarn", "--frozen-lockfile"},
+			to:       detector.NPM,
+			expected: "npm ci",
+		},
+		{
+			name:     "yarn berry up",
+			words:    []string{"yarn", "up", "react"},
+			from:     detector.YarnBerry,
+			to:       detector.Pnpm,
+			expected: "pnpm update --latest react",
+		},
+		{
+			name:     "bare script",
+			words:    []string{"yarn", "build", "--watch"},
+			to:       detector.Bun,
+			expected: "bun run build --watch",
+		},
+		{
+			name:     "scripts named like built-ins stay scripts",
+			words:    []string{"npm", "run", "test"},
+			to:       detector.Bun,
+			expected: "bun run test",
+		},
+		{
+			name:     "npx",
+			words:    []string{"npx", "--yes", "-p", "typescript", "tsc"},
+			to:       detector.YarnBerry,
+			expected: "yarn dlx --package typescript tsc",
+		},
+		{
+			name:     "deno npm binary",
+			words:    []string{"deno", "run", "-A", "npm:@scope/tool/bin", "x"},
+			to:       detector.Pnpm,
+			expected: "pnpm dlx --package=@scope/tool bin x",
+		},
+		{
+			name:     "workspace filter",
+			words:    []string{"pnpm", "--filter", "web", "add", "react"},
+			to:       detector.NPM,
+			expected: "npm --workspace=web install react",
+		},
+		{
+			name:     "yarn workspace",
+			words:    []string{"yarn", "workspace", "web", "run", "build"},
+			to:       detector.Bun,
+			expected: "bun --filter web run build",
+		},
+		{
+			name:     "recursive run",
+			words:    []string{"pnpm", "-r", "run", "build"},
+			to:       detector.YarnBerry,
+			expected: "yarn workspaces foreach --all --topological-dev run build",
+		},
+		{
+			name:    "recursive run without ordering",
+			words:   []string{"pnpm", "-r", "run", "build"},
+			to:      detector.NPM,
+			wantErr: true,
+		},
+		{
+			name:    "built-in missing from the target",
+			words:   []string{"yarn", "why", "react"},
+			to:      detector.Deno,
+			wantErr: true,
+		},
+		{
+			name:    "deno modules are not package binaries",
+			words:   []string{"deno", "run", "main.ts"},
+			to:      detector.NPM,
+			wantErr: true,
+		},
+		{
+			name:    "command of another package manager",
+			words:   []string{"npm", "ci"},
+			from:    detector.Yarn,
+			to:      detector.Pnpm,
+			wantErr: true,
+		},
+		{
+			name:     "pnpm workspace root to pnpm",
+			words:    []string{"pnpm", "add", "-w", "react"},
+			to:       detector.Pnpm,
+			expected: "pnpm -w add react",
+		},
+		{
+			name:     "pnpm workspace root to yarn",
+			words:    []string{"pnpm", "-w", "add", "-D", "react"},
+			to:       detector.Yarn,
+			expected: "yarn add --dev -W react",
+		},
+		{
+			name:     "yarn workspace root to pnpm",
+			words:    []string{"yarn", "add", "-W", "react"},
+			to:       detector.Pnpm,
+			expected: "pnpm -w add react",
+		},
+		{
+			name:     "npm has no workspace root option",
+			words:    []string{"pnpm", "add", "-Dw", "react"},
+			to:       detector.NPM,
+			expected: "npm install --save-dev react",
+			warnings: []string{"npm has no option for the workspace root: run it from the workspace root"},
+		},
+		{
+			name:     "installing covers the workspace root",
+			words:    []string{"pnpm", "install", "-w"},
+			to:       detector.Bun,
+			expected: "bun install",
+		},
+		{
+			name:     "npm test runs the test script",
+			words:    []string{"npm", "test", "--", "--watch"},
+			to:       detector.Deno,
+			expected: "deno task test -- --watch",
+		},
+		{
+			name:     "pnpm start runs the start script",
+			words:    []string{"pnpm", "start"},
+			to:       detector.Bun,
+			expected: "bun run start",
+		},
+		{
+			name:     "bun test is a test runner",
+			words:    []string{"bun", "test"},
+			to:       detector.NPM,
+			expected: "npm test",
+			warnings: []string{"bun test is copied as it is: npm test may do something else"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neutral, err := ParseNative(tt.words, tt.from)
+			var cmd *Command
+			if err == nil {
+				cmd, err = neutral.Render(tt.to)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("translating %v = %v, expected error", tt.words, cmd.Words(tt.to))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("translating %v error = %v", tt.words, err)
+			}
+
+			if actual := strings.Join(cmd.Words(tt.to), " "); actual != tt.expected {
+				t.Errorf("Expected: %s\nActual: %s", tt.expected, actual)
+			}
+			if !sliceEqual(cmd.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %v, want %v", cmd.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestRenderStrict(t *testing.T) {
+	neutral, err := ParseNative([]string{"pnpm", "add", "-w", "react"}, "")
+	if err != nil {
+		t.Fatalf("ParseNative error = %v", err)
+	}
+	if _, err := neutral.Render(detector.NPM, WithStrict(true)); err == nil {
+		t.Error("Render(npm) in strict mode succeeded, expected an error about the workspace root")
+	}
+	if _, err := neutral.Render(detector.Pnpm, WithStrict(true)); err != nil {
+		t.Errorf("Render(pnpm) in strict mode error = %v", err)
+	}
+}
+
+func TestParsePM(t *testing.T) {
+	tests := []struct {
+		input    []string
+		to       detector.PackageManager
+		expected string
+	}{
+		{input: []string{"i"}, to: detector.Bun, expected: "bun install"},
+		{input: []string{"add", "-D", "react"}, to: detector.Yarn, expected: "yarn add --dev react"},
+		{input: []string{"dev"}, to: detector.Deno, expected: "deno task dev"},
+		{input: []string{"outdated"}, to: detector.Pnpm, expected: "pnpm outdated"},
+	}
+
+	for _, tt := range tests {
+		cmd, err := ParsePM(tt.input).Render(tt.to)
+		if err != nil {
+			t.Fatalf("Render(%v) error = %v", tt.input, err)
+		}
+		if actual := strings.Join(cmd.Words(tt.to), " "); actual != tt.expected {
+			t.Errorf("Expected: %s\nActual: %s", tt.expected, actual)
+		}
+	}
+}
+
+func TestConvertText(t *testing.T) {
+	input := "```sh\n" +
+		"$ yarn add react && yarn build\n" +
+		"npm ci; npx tsc | cat\n" +
+		"echo 'yarn add react'\n" +
+		"FOO=1 npm run dev\r\n" +
+		"yarn why react\n" +
+		"yarn add -D vitest # test runner\n" +
+		"# yarn add react\n" +
+		"npm run test -- --grep '#slow'  # quoted #\n" +
+		"```\n"
+	expected := "```sh\n" +
+		"$ pnpm add react && pnpm run build\n" +
+		"pnpm install --frozen-lockfile; pnpm dlx tsc | cat\n" +
+		"echo 'yarn add react'\n" +
+		"FOO=1 npm run dev\r\n" +
+		"yarn why react\n" +
+		"pnpm add --save-dev vitest # test runner\n" +
+		"# yarn add react\n" +
+		"pnpm run test -- --grep '#slow'  # quoted #\n" +
+		"```\n"
+
+	if actual := ConvertText(input, "", detector.Pnpm); actual != expected {
+		t.Errorf("Expected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
+
+func TestTranslateWarnings(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager detector.PackageManager
+		input          []string
+		warnings       []string
+	}{
+		{
+			name:           "known flags",
+			packageManager: detector.Pnpm,
+			input:          []string{"add", "-D", "--ignore-scripts", "react"},
+		},
+		{
+			name:           "dropped omit",
+			packageManager: detector.Yarn,
+			input:          []string{"install", "--omit", "optional"},
+			warnings:       []string{"--omit optional dropped: yarn has no equivalent"},
+		},
+		{
+			name:           "npm has no frozen install",
+			packageManager: detector.NPM,
+			input:          []string{"install", "--frozen-lockfile"},
+			warnings:       []string{"--frozen-lockfile dropped: npm has no equivalent"},
+		},
+		{
+			name:           "unknown flags pass through",
+			packageManager: detector.Yarn,
+			input:          []string{"add", "-T", "--no-such-flag", "react"},
+			warnings: []string{
+				"-T passed through unchanged: not a known yarn flag",
+				"--no-such-flag passed through unchanged: not a known yarn flag",
+			},
+		},
+		{
+			name:           "yarn berry has no global packages",
+			packageManager: detector.YarnBerry,
+			input:          []string{"add", "-g", "typescript"},
+			warnings:       []string{"--global passed through unchanged: not a known yarn-berry flag"},
+		},
+		{
+			name:           "npm update has no interactive mode",
+			packageManager: detector.NPM,
+			input:          []string{"update", "-i"},
+			warnings:       []string{"--interactive dropped: npm update has no interactive mode"},
+		},
+		{
+			name:           "ci flags are translated",
+			packageManager: detector.Yarn,
+			input:          []string{"ci", "--omit", "dev", "--legacy-peer-deps"},
+			warnings:       []string{"--legacy-peer-deps passed through unchanged: not a known yarn flag"},
+		},
+		{
+			name:           "unknown ci flags pass through",
+			packageManager: detector.Pnpm,
+			input:          []string{"ci", "--bogus"},
+			warnings:       []string{"--bogus passed through unchanged: not a known pnpm flag"},
+		},
+		{
+			name:           "known ci flags",
+			packageManager: detector.NPM,
+			input:          []string{"ci", "--ignore-scripts"},
+		},
+		{
+			name:           "unknown update flags pass through",
+			packageManager: detector.Pnpm,
+			input:          []string{"update", "--no-such-flag", "react"},
+			warnings:       []string{"--no-such-flag passed through unchanged: not a known pnpm flag"},
+		},
+		{
+			name:           "known update flags",
+			packageManager: detector.NPM,
+			input:          []string{"update", "--save", "react"},
+		},
+		{
+			name:           "unknown dlx flags pass through",
+			packageManager: detector.Pnpm,
+			input:          []string{"dlx", "--no-such-flag", "-y", "create-vite"},
+			warnings: []string{
+				"--no-such-flag passed through unchanged: not a known pnpm flag",
+				"-y passed through unchanged: not a known pnpm flag",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(tt.packageManager).Translate(tt.packageManager, tt.input)
+			if err != nil {
+				t.Fatalf("Translate(%v) error = %v", tt.input, err)
+			}
+			if !sliceEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, tt.warnings)
+			}
+
+			_, err = New(tt.packageManager, WithStrict(true)).Translate(tt.packageManager, tt.input)
+			if (err != nil) != (len(tt.warnings) > 0) {
+				t.Errorf("strict Translate(%v) error = %v, want an error only for warnings", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestTablesValidate(t *testing.T) {
+	if problems := Current().Validate(); len(problems) > 0 {
+		t.Errorf("embedded tables: %q", problems)
+	}
+
+	tables, err := ParseTables([]byte(`{
+		// yarn-berry and deno are missing
+		"commands": {
+			"add": {"npm": ["install"], "yarn": ["add"], "pnpm": ["add"], "bun": ["add"]},
+			"update": {"npm": ["update"], "yarn": ["upgrade"], "yarn-berry": ["up"], "pnpm": ["update"], "bun": ["update"], "deno": ["outdated", "--update"]}
+		},
+		"flags": {"save-dev": {"npm": [{"since": "x", "args": ["--save-dev"]}], "cargo": ["-D"]}}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseTables() error = %v", err)
+	}
+	problems := tables.Validate()
+	for _, want := range []string{
+		`command "add" has no entry for yarn-berry`,
+		`command "add" has no entry for deno`,
+		`command "ci" is missing`,
+		`command "update" is translated by pm itself; its entry is ignored`,
+		`flag "save-dev" has no entry for yarn`,
+		`flag "save-dev" names unknown package manager "cargo"`,
+		`flag "save-dev" for npm has invalid version "x"`,
+		`builtIns has no entry for npm`,
+	} {
+		if !containsString(problems, want) {
+			t.Errorf("Validate() = %q, missing %q", problems, want)
+		}
+	}
+}
+
+func TestExtend(t *testing.T) {
+	saved := tables
+	tables = Current()
+	defer func() {
+		tables = saved
+		Commands = newCommands(saved)
+	}()
+
+	err := Extend([]byte(`{
+		"commands": {
+			"run": {"npm": ["run-script"]},
+			"why": {"npm": ["explain"], "yarn": ["why"], "yarn-berry": ["why"], "pnpm": ["why"], "bun": ["pm", "why"], "deno": []}
+		},
+		"flags": {
+			"save-dev": {"yarn": ["-D"]},
+			"trust": {"npm": [], "yarn": [], "yarn-berry": [], "pnpm": ["--allow-build"], "bun": ["--trust"], "deno": ["--allow-scripts"]}
+		},
+		"builtIns": {"npm": ["sbom"], "pnpm": ["licenses list"]}
+	}`))
+	if err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if problems := Current().Validate(); len(problems) > 0 {
+		t.Errorf("Validate() = %q, want no problems", problems)
+	}
+
+	tests := []struct {
+		packageManager detector.PackageManager
+		input          []string
+		expected       []string
+	}{
+		{detector.Yarn, []string{"add", "-D", "react"}, []string{"add", "-D", "react"}},
+		{detector.YarnBerry, []string{"add", "-D", "react"}, []string{"add", "--dev", "react"}},
+		{detector.Bun, []string{"add", "--trust", "esbuild"}, []string{"add", "--trust", "esbuild"}},
+		{detector.Pnpm, []string{"add", "--trust", "esbuild"}, []string{"add", "--allow-build", "esbuild"}},
+		{detector.NPM, []string{"install", "--trust"}, []string{"install"}},
+		{detector.Pnpm, []string{"licenses", "list"}, []string{"licenses", "list"}},
+		{detector.NPM, []string{"why", "--json", "react"}, []string{"explain", "--json", "react"}},
+		{detector.Bun, []string{"why", "react"}, []string{"pm", "why", "react"}},
+	}
+	for _, tt := range tests {
+		result, err := New(tt.packageManager).Translate(tt.packageManager, tt.input)
+		if err != nil {
+			t.Fatalf("Translate(%s, %v) error = %v", tt.packageManager, tt.input, err)
+		}
+		got := append(append(append([]string{}, result.Command...), result.Flags...), result.Args...)
+		if !sliceEqual(got, tt.expected) {
+			t.Errorf("Translate(%s, %v) = %v, want %v", tt.packageManager, tt.input, got, tt.expected)
+		}
+	}
+
+	if got := Commands.Run[detector.NPM]; !sliceEqual(got, []string{"run-script"}) {
+		t.Errorf("Commands.Run[npm] = %v, want [run-script]", got)
+	}
+	if got := Commands.Run[detector.Pnpm]; !sliceEqual(got, []string{"run"}) {
+		t.Errorf("Commands.Run[pnpm] = %v, want [run]", got)
+	}
+
+	if result, err := New(detector.Deno).Translate(detector.Deno, []string{"why", "react"}); err == nil {
+		t.Errorf("Translate(deno, why) = %v, want an error: deno has no equivalent", result)
+	}
+
+	if err := Extend([]byte(`{"flags": {"save-dev": {"yarn": "--dev"}}}`)); err == nil {
+		t.Error("Extend() with a string rule succeeded, want an error")
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper function to compare slices
 func sliceEqual(a, b []string) bool {
 	if len(a) != len(b) {
@@ -0,0 +1,303 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"pm/internal/detector"
+)
+
+// updateShortFlags are the short forms of the normalized update flags
+var updateShortFlags = map[rune]string{
+	'L': "latest",
+	'i': "interactive",
+	'r': "recursive",
+	'D': "dev",
+	'P': "prod",
+	'O': "optional",
+}
+
+// updateOptions are the normalized flags of `pm update`
+type updateOptions struct {
+	latest      bool
+	interactive bool
+	recursive   bool
+	dev         bool
+	prod        bool
+	optional    bool
+	// rest holds flags that are passed through untouched
+	rest []token
+}
+
+// hasTypeFilter reports whether only some dependency types should be updated
+func (o *updateOptions) hasTypeFilter() bool {
+	return o.dev || o.prod || o.optional
+}
+
+// translateUpdate maps `pm update [packages] [--latest] [--interactive]
+// [--recursive] [--dev] [--prod] [--optional]` onto each package manager
+func (t *Translator) translateUpdate(args []string) *Command {
+	parsed := t.parseArgs(expandUpdateFlags(args))
+	opts := newUpdateOptions(parsed.flags())
+	packages := parsed.packages()
+	var rules, dropped []string
+	// fromManifest lists the registry dependencies of package.json to update
+	fromManifest := func() []string {
+		registry, others := t.registryDependencies(opts)
+		for _, name := range others {
+			rules = append(rules, fmt.Sprintf("%s left alone: not a registry dependency", name))
+		}
+		return registry
+	}
+
+	// The dependency type filter becomes the matching packages from package.json
+	// unless the package manager filters on its own
+	if len(packages) == 0 && opts.hasTypeFilter() && t.expandsTypeFilter(opts) {
+		packages = fromManifest()
+		rules = append(rules, "dependency type filter expanded to the matching packages in package.json")
+	}
+
+	var prefix, command, flags []string
+	switch t.packageManager {
+	case detector.NPM:
+		if opts.latest {
+			// npm update never leaves the declared range; installing @latest rewrites package.json
+			if len(packages) == 0 {
+				packages = fromManifest()
+			}
+			if len(packages) > 0 {
+				latest := make([]string, len(packages))
+				for i, pkg := range packages {
+					latest[i] = pkg + "@latest"
+				}
+				rules = append(rules, "npm update has no --latest, installing <package>@latest instead")
+				cmd := &Command{Command: []string{"install"}, Args: latest, Rules: rules}
+				t.passThroughFlags(cmd, opts.rest)
+				return cmd
+			}
+			dropped = append(dropped, "--latest dropped: npm update has no --latest and no dependencies were found")
+		}
+		command = []string{"update"}
+		if opts.interactive {
+			dropped = append(dropped, "--interactive dropped: npm update has no interactive mode")
+		}
+		if opts.recursive {
+			flags = append(flags, "--workspaces")
+		}
+	case detector.Yarn:
+		command = []string{"upgrade"}
+		if opts.interactive {
+			command = []string{"upgrade-interactive"}
+		}
+		if opts.latest {
+			flags = append(flags, "--latest")
+		}
+		if opts.interactive && opts.hasTypeFilter() {
+			dropped = append(dropped, "dependency type filter dropped: yarn upgrade-interactive lists every dependency type")
+		}
+		if opts.recursive {
+			dropped = append(dropped, "--recursive dropped: yarn classic upgrades the current workspace only")
+		}
+	case detector.YarnBerry:
+		// yarn up moves to the latest version; -R refreshes within the declared ranges
+		command = []string{"up"}
+		switch {
+		case opts.interactive:
+			flags = append(flags, "--interactive")
+		case !opts.latest:
+			if len(packages) == 0 {
+				packages = fromManifest()
+			}
+			if len(packages) > 0 {
+				flags = append(flags, "-R")
+				rules = append(rules, "yarn up -R keeps the declared ranges")
+			} else {
+				dropped = append(dropped, "yarn up moves every dependency to its latest version: no package.json dependencies to keep in range")
+			}
+		}
+		if len(packages) == 0 {
+			packages = []string{"*"}
+		}
+		if opts.recursive {
+			prefix = []string{"workspaces", "foreach", "--all"}
+			rules = append(rules, "--recursive → yarn workspaces foreach --all")
+		}
+	case detector.Pnpm:
+		command = []string{"update"}
+		if opts.latest {
+			flags = append(flags, "--latest")
+		}
+		if opts.interactive {
+			flags = append(flags, "--interactive")
+		}
+		if opts.recursive {
+			flags = append(flags, "--recursive")
+		}
+		if !opts.optional && opts.dev != opts.prod {
+			if opts.dev {
+				flags = append(flags, "--dev")
+			} else {
+				flags = append(flags, "--prod")
+			}
+		}
+	case detector.Bun:
+		command = []string{"update"}
+		if opts.latest {
+			flags = append(flags, "--latest")
+		}
+		if opts.interactive && t.atLeast("1.2.19") {
+			flags = append(flags, "--interactive")
+		} else if opts.interactive {
+			dropped = append(dropped, fmt.Sprintf("--interactive dropped: bun %s predates it", t.version))
+		}
+		if opts.recursive && t.atLeast("1.2.19") {
+			flags = append(flags, "--recursive")
+		} else if opts.recursive {
+			dropped = append(dropped, fmt.Sprintf("--recursive dropped: bun %s predates it", t.version))
+		}
+	case detector.Deno:
+		command = []string{"outdated", "--update"}
+		if opts.latest {
+			flags = append(flags, "--latest")
+		}
+		if opts.interactive {
+			flags = append(flags, "--interactive")
+		}
+		if opts.recursive {
+			flags = append(flags, "--recursive")
+		}
+	}
+
+	cmd := &Command{
+		Prefix:   prefix,
+		Command:  command,
+		Flags:    flags,
+		Args:     append(packages, parsed.rest...),
+		Rules:    append(rules, dropped...),
+		Warnings: dropped,
+	}
+	t.passThroughFlags(cmd, opts.rest)
+	return cmd
+}
+
+func (t *Translator) expandsTypeFilter(opts *updateOptions) bool {
+	switch t.packageManager {
+	case detector.Pnpm:
+		// pnpm filters dev and prod dependencies but has no optional-only filter
+		return opts.optional
+	case detector.Yarn:
+		// yarn upgrade-interactive does not take package names
+		return !opts.interactive
+	}
+	return true
+}
+
+func newUpdateOptions(flags []token) *updateOptions {
+	opts := &updateOptions{}
+
+	for _, flag := range flags {
+		switch flag.flag {
+		case "latest":
+			opts.latest = true
+		case "interactive":
+			opts.interactive = true
+		case "recursive":
+			opts.recursive = true
+		case "dev", "save-dev":
+			opts.dev = true
+		case "prod", "production":
+			opts.prod = true
+		case "optional", "save-optional":
+			opts.optional = true
+		default:
+			opts.rest = append(opts.rest, flag)
+		}
+	}
+
+	return opts
+}
+
+// expandUpdateFlags spells out grouped short flags such as -iL, which mean
+// something else for the other commands
+func expandUpdateFlags(args []string) []string {
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") || len(arg) < 2 {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		var flags []string
+		for _, short := range arg[1:] {
+			name, ok := updateShortFlags[short]
+			if !ok {
+				flags = nil
+				break
+			}
+			flags = append(flags, "--"+name)
+		}
+		if flags == nil {
+			expanded = append(expanded, arg)
+			continue
+		}
+		expanded = append(expanded, flags...)
+	}
+	return expanded
+}
+
+// dependencies lists the package.json dependencies of the requested types,
+// or all of them without a type filter
+func (t *Translator) dependencies(opts *updateOptions) []string {
+	if t.manifest == nil {
+		return nil
+	}
+
+	all := !opts.hasTypeFilter()
+	var names []string
+	if all || opts.prod {
+		names = appendKeys(names, t.manifest.Dependencies)
+	}
+	if all || opts.dev {
+		names = appendKeys(names, t.manifest.DevDependencies)
+	}
+	if all || opts.optional {
+		names = appendKeys(names, t.manifest.OptionalDependencies)
+	}
+	return names
+}
+
+// registryDependencies lists the package.json dependencies of the requested
+// types that come from the registry, and the others, which cannot be
+// requested by version: workspace:, file:, link:, git and the like
+func (t *Translator) registryDependencies(opts *updateOptions) (registry, others []string) {
+	for _, name := range t.dependencies(opts) {
+		version := t.manifest.Dependencies[name]
+		if version == "" {
+			version = t.manifest.DevDependencies[name]
+		}
+		if version == "" {
+			version = t.manifest.OptionalDependencies[name]
+		}
+		if version == "" {
+			version = "*"
+		}
+
+		spec, err := ParseSpec(name + "@" + version)
+		if err != nil || spec.Kind != SpecRegistry {
+			others = append(others, name)
+			continue
+		}
+		registry = append(registry, name)
+	}
+	return registry, others
+}
+
+func appendKeys(names []string, deps map[string]string) []string {
+	keys := make([]string, 0, len(deps))
+	for name := range deps {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return append(names, keys...)
+}
@@ -0,0 +1,59 @@
+package translator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"pm/internal/detector"
+)
+
+// ForWorkspaces rewrites a translated command to run in the named workspace
+// packages. The command runs from the workspace root.
+func (t *Translator) ForWorkspaces(cmd *Command, rootDir string, names []string) (*Command, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no workspace packages selected")
+	}
+	if cmd.Binary != "" {
+		return nil, fmt.Errorf("%s cannot be run in selected workspace packages", cmd.Binary)
+	}
+
+	var prefix []string
+	switch t.packageManager {
+	case detector.NPM:
+		if !t.atLeast("7") {
+			return nil, fmt.Errorf("npm %s does not support workspaces", t.version)
+		}
+		for _, name := range names {
+			prefix = append(prefix, "--workspace="+name)
+		}
+	case detector.Yarn:
+		if len(names) > 1 {
+			return nil, fmt.Errorf("yarn classic runs commands in one workspace at a time, but %d were selected", len(names))
+		}
+		prefix = []string{"workspace", names[0]}
+	case detector.YarnBerry:
+		prefix = []string{"workspaces", "foreach", "--all"}
+		for _, name := range names {
+			prefix = append(prefix, "--include", name)
+		}
+	case detector.Bun:
+		// bun add and bun remove ignore --filter and change the root package
+		if slices.Equal(cmd.Command, Commands.Add[t.packageManager]) || slices.Equal(cmd.Command, Commands.Uninstall[t.packageManager]) {
+			return nil, fmt.Errorf("bun cannot %s packages in selected workspace packages; run it from the package directory", cmd.Command[0])
+		}
+		fallthrough
+	case detector.Pnpm:
+		for _, name := range names {
+			prefix = append(prefix, "--filter", name)
+		}
+	default:
+		return nil, fmt.Errorf("%s does not support workspace filters", t.packageManager)
+	}
+
+	filtered := *cmd
+	filtered.Prefix = append(prefix, cmd.Prefix...)
+	filtered.Dir = rootDir
+	filtered.Rules = append(slices.Clone(cmd.Rules), fmt.Sprintf("workspace packages %s → %s", strings.Join(names, ", "), strings.Join(prefix, " ")))
+	return &filtered, nil
+}
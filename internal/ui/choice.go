@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"pm/internal/detector"
+	"pm/internal/project"
+)
+
+// ShowPackageManagerPrompt lets the user choose one of the given package
+// managers, showing the versions installed for the workspace at rootDir
+func ShowPackageManagerPrompt(pms []detector.PackageManager, rootDir string) (detector.PackageManager, error) {
+	choices := make([]project.Script, len(pms))
+	for i, pm := range pms {
+		description := "installed"
+		if version, err := detector.InstalledVersion(pm, rootDir); err == nil {
+			description = "installed version " + version
+		}
+		choices[i] = project.Script{Name: string(pm), Command: description}
+	}
+
+	selected, err := runPrompt(choices)
+	if err != nil {
+		return "", err
+	}
+	return detector.PackageManager(selected.Name), nil
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+)
+
+// Confirm asks a yes/no question and returns true only when the user answers yes
+func Confirm(question string) (bool, error) {
+	oldState, err := enableRawMode()
+	if err != nil {
+		return false, err
+	}
+	defer restoreTerminal(oldState)
+
+	fmt.Print(question + " [y/N] ")
+
+	for {
+		key, err := readKey()
+		if err != nil {
+			fmt.Print(newline)
+			return false, err
+		}
+		if len(key) != 1 {
+			continue
+		}
+
+		switch key[0] {
+		case 'y', 'Y':
+			fmt.Print("y" + newline)
+			return true, nil
+		case 'n', 'N', 13, 10, 27:
+			fmt.Print("n" + newline)
+			return false, nil
+		case 3:
+			fmt.Print(newline)
+			return false, fmt.Errorf("cancelled")
+		}
+	}
+}
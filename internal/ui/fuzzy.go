@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -78,3 +79,79 @@ func fuzzyScore(text, query string) int {
 
 	return score
 }
+
+// Suggest returns up to limit candidates resembling query, best first. Fuzzy
+// matches in either direction rank first, then near misses such as typos.
+func Suggest(query string, candidates []string, limit int) []string {
+	type suggestion struct {
+		name  string
+		score int
+	}
+
+	maxDistance := len([]rune(query)) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	seen := map[string]bool{}
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		if seen[candidate] || candidate == query {
+			continue
+		}
+		seen[candidate] = true
+
+		score := fuzzyScore(candidate, query)
+		// A query may also contain the candidate, e.g. "buildd" for "build",
+		// as long as the candidate is not so short that it matches anything
+		if 3*len([]rune(candidate)) >= 2*len([]rune(query)) {
+			score = min(score, fuzzyScore(query, candidate))
+		}
+		if score == 999999 {
+			distance := editDistance(strings.ToLower(candidate), strings.ToLower(query))
+			if distance > maxDistance {
+				continue
+			}
+			// Rank typos after every fuzzy match
+			score = 100000 + distance
+		}
+		suggestions = append(suggestions, suggestion{candidate, score})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].score < suggestions[j].score
+	})
+
+	var names []string
+	for _, s := range suggestions {
+		if len(names) == limit {
+			break
+		}
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(min(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
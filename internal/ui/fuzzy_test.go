@@ -196,3 +196,49 @@ func TestFuzzyMatchCaseInsensitive(t *testing.T) {
 		})
 	}
 }
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"build", "build:prod", "dev", "eslint", "test", "x"}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "fuzzy matches rank by score",
+			query: "bld",
+			want:  []string{"build", "build:prod"},
+		},
+		{
+			name:  "query containing the candidate",
+			query: "buildd",
+			want:  []string{"build", "build:prod"},
+		},
+		{
+			name:  "typos",
+			query: "eslnit",
+			want:  []string{"eslint"},
+		},
+		{
+			name:  "nothing similar",
+			query: "deploy",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suggest(tt.query, candidates, 3)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Suggest(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Suggest(%q) = %v, want %v", tt.query, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
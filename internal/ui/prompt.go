@@ -1,10 +1,10 @@
 package ui
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"time"
 	"unicode"
@@ -468,26 +468,17 @@ func (ui *PromptUI) render() (startIdx, endIdx int) {
 }
 
 // ShowScriptPrompt displays an interactive prompt for selecting a script
-func ShowScriptPrompt() (*project.Script, error) {
-	packageJSONPath, err := detector.FindPackageJSON()
-	if err != nil {
-		return nil, fmt.Errorf("cannot find package.json: %v", err)
-	}
-
-	data, err := os.ReadFile(packageJSONPath)
+func ShowScriptPrompt(pm detector.PackageManager) (*project.Script, error) {
+	scripts, err := loadScripts(pm)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read package.json: %v", err)
-	}
-
-	var pkg project.PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return nil, fmt.Errorf("cannot parse package.json: %v", err)
+		return nil, err
 	}
 
-	if len(pkg.OrderedScripts) == 0 {
-		return nil, fmt.Errorf("no scripts found in package.json")
-	}
+	return runPrompt(scripts)
+}
 
+// runPrompt lets the user pick one of the given entries in a full screen list
+func runPrompt(scripts []project.Script) (*project.Script, error) {
 	oldState, err := enableRawMode()
 	if err != nil {
 		return nil, err
@@ -503,7 +494,7 @@ func ShowScriptPrompt() (*project.Script, error) {
 		fmt.Print(showCursorCode)
 	}()
 
-	ui := NewPromptUI(pkg.OrderedScripts)
+	ui := NewPromptUI(scripts)
 
 	// Set up terminal resize handling
 	sigChan := make(chan os.Signal, 1)
@@ -579,3 +570,34 @@ func ShowScriptPrompt() (*project.Script, error) {
 		}
 	}
 }
+
+func loadScripts(pm detector.PackageManager) ([]project.Script, error) {
+	if pm == detector.Deno {
+		if configPath, err := detector.FindDenoConfig(); err == nil {
+			config, err := project.ReadDenoConfig(configPath)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %s: %v", filepath.Base(configPath), err)
+			}
+			if len(config.OrderedTasks) == 0 {
+				return nil, fmt.Errorf("no tasks found in %s", filepath.Base(configPath))
+			}
+			return config.OrderedTasks, nil
+		}
+	}
+
+	packageJSONPath, err := detector.FindPackageJSON()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find package.json: %v", err)
+	}
+
+	pkg, err := project.ReadPackageJSON(packageJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkg.OrderedScripts) == 0 {
+		return nil, fmt.Errorf("no scripts found in package.json")
+	}
+
+	return pkg.OrderedScripts, nil
+}
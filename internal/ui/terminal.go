@@ -126,3 +126,8 @@ func parseMouseEvent(data []byte) (*MouseEvent, bool) {
 
 	return event, event.Type != ""
 }
+
+// IsInteractive reports whether stdin and stdout are attached to a terminal
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
 
 	"github.com/spf13/cobra"
 
-	"pm/internal/detector"
 	"pm/internal/executor"
 	"pm/internal/translator"
 	"pm/internal/ui"
@@ -15,38 +16,13 @@ import (
 )
 
 func main() {
+	status := 0
 	var rootCmd = &cobra.Command{
 		Use:                "pm",
 		Short:              "A universal package manager wrapper",
 		DisableFlagParsing: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 1 && (args[0] == "-v" || args[0] == "--version") {
-				fmt.Println(version.GetVersion())
-				return
-			}
-
-			pm, err := detector.Detect()
-			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				return
-			}
-			if len(args) == 0 {
-				script, err := ui.ShowScriptPrompt()
-				if err != nil {
-					if err.Error() != "cancelled" {
-						fmt.Fprintln(os.Stderr, err)
-					}
-					return
-				}
-				executor.Run(pm, translator.Commands.Run, script.Name)
-				return
-			}
-
-			tr := translator.New(pm)
-			translated := tr.Translate(pm, args)
-			if err := executor.Execute(pm, translated); err != nil {
-				fmt.Fprintln(os.Stderr, err)
-			}
+			status = run(args)
 		},
 		Args: cobra.ArbitraryArgs,
 	}
@@ -54,4 +30,86 @@ func main() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
+	os.Exit(status)
+}
+
+// run runs pm with its command line arguments and returns the exit status:
+// the status of a failed package manager command, or 1 when pm itself fails
+func run(args []string) int {
+	if len(args) == 1 && (args[0] == "-v" || args[0] == "--version") {
+		fmt.Println(version.GetVersion())
+		return 0
+	}
+	opts, args, err := parseGlobalOptions(args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(args) > 0 && args[0] == "translate" {
+		return fail(runTranslate(opts, args[1:]))
+	}
+	if len(args) > 0 && (args[0] == "which" || args[0] == "--explain-detection") {
+		return fail(runWhich(opts, args[1:]))
+	}
+
+	result, err := resolvePackageManager(opts)
+	if err != nil {
+		return fail(err)
+	}
+	pm := result.PackageManager
+	loadTranslations(result.Config)
+	corepack, err := executor.EnsureVersion(result)
+	if err != nil {
+		return fail(err)
+	}
+	if len(args) == 0 && len(opts.filters) > 0 {
+		return fail(fmt.Errorf("--filter needs a command to run"))
+	}
+	if len(args) == 0 {
+		script, err := ui.ShowScriptPrompt(pm)
+		if err != nil {
+			if err.Error() == "cancelled" {
+				return 0
+			}
+			return fail(err)
+		}
+		translated, err := translator.New(pm, translatorOptions(result, opts)...).Script(script.Name)
+		if err != nil {
+			reportTranslateError(err)
+			return 1
+		}
+		return runTranslated(pm, corepack, opts, translated)
+	}
+
+	if run, ok, err := parseRecursiveRun(pm, args); ok {
+		if err == nil {
+			err = runRecursive(result, corepack, opts, run)
+		}
+		return fail(err)
+	}
+
+	translated, err := translateCommand(result, opts, args)
+	if err != nil {
+		reportTranslateError(err)
+		return 1
+	}
+	return runTranslated(pm, corepack, opts, translated)
+}
+
+// fail prints err and returns the exit status for it, 0 without an error.
+// A failed package manager command passes its exit status on; when it failed
+// on its own it already explained why.
+func fail(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	isExit := errors.As(err, &exitErr)
+	if !isExit || err != error(exitErr) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if isExit && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
 }
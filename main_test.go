@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunExitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		args   []string
+		status int
+		// posix needs a POSIX shell
+		posix bool
+	}{
+		{
+			name:   "dry run",
+			files:  map[string]string{"package-lock.json": "{}"},
+			args:   []string{"--dry-run", "add", "react"},
+			status: 0,
+		},
+		{
+			name:   "strict mode refuses unknown flags",
+			files:  map[string]string{"package-lock.json": "{}"},
+			args:   []string{"--dry-run", "--strict", "add", "--bogus", "react"},
+			status: 1,
+		},
+		{
+			name: "lockfile policy error",
+			files: map[string]string{
+				"package-lock.json": "{}",
+				"pnpm-lock.yaml":    "",
+				".pmrc.json":        `{"lockfilePolicy":"error"}`,
+			},
+			args:   []string{"--dry-run", "install"},
+			status: 1,
+		},
+		{
+			name:   "unsupported package manager",
+			files:  map[string]string{"package-lock.json": "{}"},
+			args:   []string{"--pm", "cargo", "install"},
+			status: 1,
+		},
+		{
+			name:   "failing script passes its status on",
+			files:  map[string]string{"package-lock.json": "{}"},
+			args:   []string{"--direct", "fail"},
+			status: 3,
+			posix:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.posix && runtime.GOOS == "windows" {
+				t.Skip("needs a POSIX shell")
+			}
+			dir := t.TempDir()
+			t.Setenv("PM_CONFIG", filepath.Join(dir, "missing.json"))
+			t.Setenv("PM_CACHE_DIR", "off")
+			t.Setenv("PM_PACKAGE_MANAGER", "")
+			t.Setenv("npm_config_user_agent", "")
+
+			files := map[string]string{"package.json": `{"name":"app","scripts":{"fail":"exit 3"}}`}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			if err := os.Chdir(dir); err != nil {
+				t.Fatalf("Failed to change dir: %v", err)
+			}
+
+			if status := run(tt.args); status != tt.status {
+				t.Errorf("run(%v) = %d, want %d", tt.args, status, tt.status)
+			}
+		})
+	}
+}
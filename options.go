@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"pm/internal/config"
+)
+
+// dryRunEnv turns on dry-run mode like --dry-run
+const dryRunEnv = "PM_DRY_RUN"
+
+// globalOptions are pm's own options, accepted before the command and
+// removed from the arguments before they are translated
+type globalOptions struct {
+	// packageManager forces a package manager for this invocation
+	packageManager string
+	// filters select the workspace packages to run the command in
+	filters []string
+	// dryRun prints the commands pm would run instead of running them
+	dryRun bool
+	// strict fails instead of warning about flags that cannot be translated
+	strict bool
+	// hooks overrides the hooks config for this invocation: "always" or "never"
+	hooks string
+	// scripts overrides the scripts config for this invocation: "direct" or "package-manager"
+	scripts string
+}
+
+func parseGlobalOptions(args []string) (globalOptions, []string, error) {
+	opts := globalOptions{dryRun: isTruthy(os.Getenv(dryRunEnv))}
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		// After the command, options belong to the package manager, a script
+		// or a dlx tool, e.g. turbo run build --filter=web
+		beforeCommand := len(rest) == 0
+
+		switch {
+		case arg == "--pm" && beforeCommand:
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("--pm requires a package manager name")
+			}
+			opts.packageManager = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--pm=") && beforeCommand:
+			opts.packageManager = strings.TrimPrefix(arg, "--pm=")
+		case (arg == "--filter" || arg == "-w") && beforeCommand:
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("%s requires a workspace package", arg)
+			}
+			opts.filters = append(opts.filters, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--filter=") && beforeCommand:
+			opts.filters = append(opts.filters, strings.TrimPrefix(arg, "--filter="))
+		case arg == "--dry-run" && beforeCommand:
+			// After the command, --dry-run belongs to the package manager
+			opts.dryRun = true
+		case arg == "--strict" && beforeCommand:
+			opts.strict = true
+		case arg == "--hooks" && beforeCommand:
+			opts.hooks = config.HooksAlways
+		case arg == "--no-hooks" && beforeCommand:
+			opts.hooks = config.HooksNever
+		case arg == "--direct" && beforeCommand:
+			opts.scripts = config.ScriptsDirect
+		case arg == "--no-direct" && beforeCommand:
+			opts.scripts = config.ScriptsPackageManager
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return opts, rest, nil
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGlobalOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		pm      string
+		filters []string
+		rest    []string
+	}{
+		{
+			name:    "filters before the command",
+			args:    []string{"--filter", "web", "-w", "api", "--filter=docs", "build"},
+			filters: []string{"web", "api", "docs"},
+			rest:    []string{"build"},
+		},
+		{
+			name: "a script keeps its own --filter",
+			args: []string{"turbo", "run", "build", "--filter=b"},
+			rest: []string{"turbo", "run", "build", "--filter=b"},
+		},
+		{
+			name: "a dlx tool keeps its own --filter",
+			args: []string{"dlx", "turbo", "run", "build", "--filter", "b"},
+			rest: []string{"dlx", "turbo", "run", "build", "--filter", "b"},
+		},
+		{
+			name: "package manager before the command",
+			args: []string{"--pm", "pnpm", "--pm=bun", "add", "react"},
+			pm:   "bun",
+			rest: []string{"add", "react"},
+		},
+		{
+			name: "a dlx tool keeps its own --pm",
+			args: []string{"dlx", "some-tool", "--pm", "foo", "--pm=bar"},
+			rest: []string{"dlx", "some-tool", "--pm", "foo", "--pm=bar"},
+		},
+		{
+			name: "options after -- are kept",
+			args: []string{"--", "--filter", "web"},
+			rest: []string{"--", "--filter", "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(dryRunEnv, "")
+			opts, rest, err := parseGlobalOptions(tt.args)
+			if err != nil {
+				t.Fatalf("parseGlobalOptions(%v) error = %v", tt.args, err)
+			}
+			if opts.packageManager != tt.pm {
+				t.Errorf("packageManager = %q, want %q", opts.packageManager, tt.pm)
+			}
+			if !slices.Equal(opts.filters, tt.filters) {
+				t.Errorf("filters = %q, want %q", opts.filters, tt.filters)
+			}
+			if !slices.Equal(rest, tt.rest) {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
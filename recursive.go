@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"pm/internal/detector"
+	"pm/internal/executor"
+	"pm/internal/project"
+	"pm/internal/translator"
+)
+
+// recursiveRun is a script run across workspace packages, from pm -r <script>
+// or pm run --recursive <script>
+type recursiveRun struct {
+	script string
+	args   []string
+	opts   executor.RecursiveOptions
+}
+
+// parseRecursiveRun recognizes a recursive script run. Flags before the script
+// name belong to pm, everything after it is passed to the script. Other pnpm
+// commands such as pnpm -r install are left to pnpm.
+func parseRecursiveRun(pm detector.PackageManager, args []string) (recursiveRun, bool, error) {
+	var run recursiveRun
+	if len(args) == 0 {
+		return run, false, nil
+	}
+
+	rest := args
+	recursive := false
+	switch args[0] {
+	case "-r", "--recursive":
+		recursive = true
+		rest = args[1:]
+		if len(rest) > 0 && rest[0] == "run" {
+			rest = rest[1:]
+		} else if len(rest) > 0 && pm == detector.Pnpm && translator.IsBuiltIn(pm, rest[0]) {
+			return run, false, nil
+		}
+	case "run":
+		rest = args[1:]
+	default:
+		return run, false, nil
+	}
+
+	for i, arg := range rest {
+		switch arg {
+		case "-r", "--recursive":
+			recursive = true
+		case "--parallel":
+			run.opts.Parallel = true
+		case "--if-present":
+			run.opts.IfPresent = true
+		default:
+			if !recursive {
+				return run, false, nil
+			}
+			run.script = arg
+			run.args = rest[i+1:]
+			return run, true, nil
+		}
+	}
+
+	if !recursive {
+		return run, false, nil
+	}
+	return run, true, fmt.Errorf("a recursive run needs a script name")
+}
+
+// runRecursive runs a script in every workspace package defining it, through
+// the package manager's own recursive run when it keeps the dependency order
+func runRecursive(result *detector.Result, corepack string, opts globalOptions, run recursiveRun) error {
+	if result.RootDir == "" {
+		return fmt.Errorf("a recursive run needs a workspace, but no package.json was found")
+	}
+	pm := result.PackageManager
+	run.opts.Version = packageManagerVersion(result)
+	run.opts.Hooks = hookPolicy(opts, result.Config)
+	run.opts.Corepack = corepack
+
+	// The package manager's own recursive run leaves pre and post scripts to it
+	if len(opts.filters) == 0 && run.opts.Hooks == translator.HooksDefault {
+		tr := translator.New(pm, translator.WithVersion(run.opts.Version))
+		if cmd, ok := tr.RecursiveRun(run.script, run.args, run.opts.Parallel, run.opts.IfPresent); ok {
+			cmd.Dir = result.RootDir
+			if opts.dryRun {
+				executor.DryRun(os.Stdout, pm, corepack, cmd)
+				return nil
+			}
+			return executor.Execute(pm, corepack, cmd)
+		}
+	}
+
+	workspace, err := project.LoadWorkspace(result.RootDir)
+	if err != nil {
+		return err
+	}
+	packages := workspace.Packages
+	if len(opts.filters) > 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("cannot get current directory: %v", err)
+		}
+		if packages, err = workspace.Select(opts.filters, cwd); err != nil {
+			return err
+		}
+	}
+
+	if opts.dryRun {
+		return executor.DryRunRecursive(os.Stdout, pm, packages, run.script, run.args, run.opts)
+	}
+	return executor.RunRecursive(pm, packages, run.script, run.args, run.opts)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"pm/internal/config"
+	"pm/internal/detector"
+	"pm/internal/shell"
+	"pm/internal/translator"
+)
+
+// translateOptions are the options of pm translate
+type translateOptions struct {
+	// from is the package manager the input is written for; empty detects it
+	// from each command, and pmSource reads pm commands
+	from     detector.PackageManager
+	pmSource bool
+	// to is the target package manager; empty with all set
+	to    detector.PackageManager
+	all   bool
+	files []string
+	words []string
+}
+
+// runTranslate implements
+//
+//	pm translate "<command>" [--from <pm>] --to <pm>|all
+//	pm translate [--from <pm>] --to <pm> [--file <path>]...
+//
+// The first form translates one command line; without a command the files,
+// or standard input, are converted as shell scripts or READMEs. Warnings about
+// the translation go to standard error, or fail it with --strict.
+func runTranslate(global globalOptions, args []string) error {
+	opts, err := parseTranslateOptions(args)
+	if err != nil {
+		return err
+	}
+
+	// Outside a project only the user config applies
+	rootDir, _ := detector.FindWorkspaceRoot()
+	cfg := config.Load(rootDir)
+	loadTranslations(cfg)
+	strict := translator.WithStrict(global.strict || cfg.Strict)
+
+	if len(opts.words) == 0 {
+		if opts.all {
+			return fmt.Errorf("--to all needs a command to translate")
+		}
+		return convertFiles(opts)
+	}
+
+	neutral, err := parseTranslateInput(opts)
+	if err != nil {
+		return err
+	}
+
+	if !opts.all {
+		cmd, err := neutral.Render(opts.to, strict)
+		if err != nil {
+			return err
+		}
+		reportTranslateWarnings(cmd)
+		fmt.Println(shell.Join(cmd.Words(opts.to)))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, pm := range detector.Supported {
+		cmd, err := neutral.Render(pm, strict)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t(%v)\n", pm, err)
+			continue
+		}
+		line := shell.Join(cmd.Words(pm))
+		if len(cmd.Warnings) > 0 {
+			line += "  (warning: " + strings.Join(cmd.Warnings, "; ") + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", pm, line)
+	}
+	return w.Flush()
+}
+
+func parseTranslateOptions(args []string) (translateOptions, error) {
+	var opts translateOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name != "--from" && name != "--to" && name != "--file" {
+			positional = append(positional, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", name)
+			}
+			value = args[i+1]
+			i++
+		}
+
+		switch name {
+		case "--from":
+			if value == "pm" {
+				opts.pmSource = true
+				continue
+			}
+			pm, ok := detector.ParsePackageManager(value)
+			if !ok {
+				return opts, fmt.Errorf("unsupported package manager: %s (supported: pm, npm, yarn, yarn-berry, pnpm, bun, deno)", value)
+			}
+			opts.from = pm
+		case "--to":
+			if value == "all" {
+				opts.all = true
+				continue
+			}
+			pm, ok := detector.ParsePackageManager(value)
+			if !ok {
+				return opts, fmt.Errorf("unsupported package manager: %s (supported: npm, yarn, yarn-berry, pnpm, bun, deno, all)", value)
+			}
+			opts.to = pm
+		case "--file":
+			opts.files = append(opts.files, value)
+		}
+	}
+
+	if opts.to == "" && !opts.all {
+		return opts, fmt.Errorf("pm translate needs --to <package manager> or --to all")
+	}
+	if len(positional) > 0 && len(opts.files) > 0 {
+		return opts, fmt.Errorf("pm translate takes either a command or --file, not both")
+	}
+
+	// A single argument is a quoted command line
+	if len(positional) == 1 {
+		words, err := shell.Split(positional[0])
+		if err != nil {
+			return opts, err
+		}
+		positional = words
+	}
+	opts.words = positional
+	return opts, nil
+}
+
+// parseTranslateInput reads the command to translate, which is a pm command
+// unless it starts with the executable of a package manager or --from names one
+func parseTranslateInput(opts translateOptions) (*translator.Neutral, error) {
+	words := opts.words
+	if words[0] == "pm" {
+		words = words[1:]
+	} else if !opts.pmSource && (opts.from != "" || translator.IsNativeCommand(words)) {
+		return translator.ParseNative(words, opts.from)
+	}
+
+	global, args, err := parseGlobalOptions(words)
+	if err != nil {
+		return nil, err
+	}
+
+	if run, ok, err := parseRecursiveRun("", args); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &translator.Neutral{Args: append([]string{run.script}, run.args...), Workspaces: global.filters, Recursive: true}, nil
+	}
+
+	neutral := translator.ParsePM(args)
+	neutral.Workspaces = global.filters
+	return neutral, nil
+}
+
+// convertFiles converts the package manager commands in files, or in
+// standard input without files, writing the result to standard output
+func convertFiles(opts translateOptions) error {
+	if len(opts.files) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("cannot read standard input: %v", err)
+		}
+		fmt.Print(translator.ConvertText(string(data), opts.from, opts.to))
+		return nil
+	}
+
+	for _, file := range opts.files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %v", file, err)
+		}
+		fmt.Print(translator.ConvertText(string(data), opts.from, opts.to))
+	}
+	return nil
+}
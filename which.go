@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"pm/internal/detector"
+)
+
+// runWhich prints how the package manager was detected for the current directory
+func runWhich(opts globalOptions, args []string) error {
+	asJSON := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		default:
+			return fmt.Errorf("unknown option for which: %s", arg)
+		}
+	}
+
+	result, detectErr := detector.Resolve()
+	if opts.packageManager != "" {
+		result, detectErr = detector.ResolveForced(opts.packageManager)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printDetection(os.Stdout, result)
+	}
+
+	return detectErr
+}
+
+func printDetection(out io.Writer, result *detector.Result) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "package manager:\t%s\n", valueOrDash(string(result.PackageManager)))
+	fmt.Fprintf(w, "version:\t%s\n", valueOrDash(result.Version))
+	fmt.Fprintf(w, "package dir:\t%s\n", valueOrDash(result.PackageDir))
+	fmt.Fprintf(w, "workspace root:\t%s\n", valueOrDash(result.RootDir))
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "warning:\t%s\n", warning)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "SOURCE\tDETAIL\tMANAGER\tRESULT")
+	for _, evidence := range result.Evidence {
+		status := "used"
+		if !evidence.Accepted {
+			status = "rejected: " + evidence.Reason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			evidence.Source,
+			evidence.Detail,
+			valueOrDash(string(evidence.PackageManager)),
+			status,
+		)
+	}
+
+	w.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"pm/internal/detector"
+	"pm/internal/project"
+)
+
+// selectWorkspacePackages resolves --filter selectors to workspace package names
+func selectWorkspacePackages(result *detector.Result, filters []string) ([]string, error) {
+	if result.RootDir == "" {
+		return nil, fmt.Errorf("--filter needs a workspace, but no package.json was found")
+	}
+
+	workspace, err := project.LoadWorkspace(result.RootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get current directory: %v", err)
+	}
+
+	packages, err := workspace.Select(filters, cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(packages))
+	for i, pkg := range packages {
+		if pkg.Name == "" {
+			return nil, fmt.Errorf("workspace package in %s has no name", pkg.Dir)
+		}
+		names[i] = pkg.Name
+	}
+	return names, nil
+}